@@ -9,7 +9,7 @@ import (
 var archiCmd = &cobra.Command{
 	Use:   "architecture",
 	Short: "Generate architectural recommendations",
-	Long: `Analyze existing output.json and output.md files to generate 
+	Long: `Analyze the existing output.json file to generate 
 comprehensive architectural recommendations. This command requires that 
 you have already run a full analysis to generate the input files.`,
 	Aliases: []string{"arch", "archi"},
@@ -24,6 +24,40 @@ you have already run a full analysis to generate the input files.`,
 	},
 }
 
+var (
+	applyExecute   bool
+	applyGitScript string
+	applyRoot      string
+)
+
+var archiApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Scaffold the recommended folder structure",
+	Long: `Compute the moves needed to turn the analyzed tree into the recommended
+folder structure produced by the architecture command, and show them as a dry
+run. Use --execute to create the folders and move the files (all changes are
+rolled back if a step fails), or --git-script to write a script of git mv
+commands instead.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfigFromGlobal()
+		if err != nil {
+			return err
+		}
+
+		application := app.New(cfg)
+		return application.ApplyArchitectureLayout(app.ApplyOptions{
+			Root:      applyRoot,
+			Execute:   applyExecute,
+			GitScript: applyGitScript,
+		})
+	},
+}
+
 func init() {
+	archiApplyCmd.Flags().BoolVar(&applyExecute, "execute", false, "create the folders and move the files")
+	archiApplyCmd.Flags().StringVar(&applyGitScript, "git-script", "", "write a git mv script to this file instead of moving files")
+	archiApplyCmd.Flags().StringVar(&applyRoot, "root", "", "project root (default: the root recorded in the JSON output)")
+	archiCmd.AddCommand(archiApplyCmd)
 	rootCmd.AddCommand(archiCmd)
 }
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"archi/internal/app"
+)
+
+var diffSummarize bool
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old.json> <new.json>",
+	Short: "Compare two analyses",
+	Long: `Compare two JSON outputs of archi, for example the output.json kept from two
+releases. The comparison lists the files and folders added and removed, the
+files moved (same content hash at another path) and modified, the descriptions
+that changed significantly and the folders whose size changed. It is written as
+markdown and JSON in the output directory. Use --summarize to have the
+architecture model summarize the evolution.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfigFromGlobal()
+		if err != nil {
+			return err
+		}
+
+		application := app.New(cfg)
+		return application.DiffAnalyses(app.DiffOptions{
+			OldFile:   args[0],
+			NewFile:   args[1],
+			Summarize: diffSummarize,
+		})
+	},
+}
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffSummarize, "summarize", false, "ask the architecture model to summarize the evolution")
+	rootCmd.AddCommand(diffCmd)
+}
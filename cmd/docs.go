@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"archi/internal/app"
+)
+
+var (
+	docsMirrorDir string
+	docsDryRun    bool
+)
+
+var docsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Generate documentation from an existing analysis",
+	Long:  `Generate documentation files from the output.json produced by a previous analysis.`,
+}
+
+var docsWriteCmd = &cobra.Command{
+	Use:   "write",
+	Short: "Write a README.md into each analyzed folder",
+	Long: `Write a README.md into each analyzed folder containing the folder description
+and an index of its files. Folders that already have a README are skipped unless
+the README was generated by archi. Use --mirror to write the READMEs into a
+separate directory tree, and --dry-run to preview the changes as a diff.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfigFromGlobal()
+		if err != nil {
+			return err
+		}
+
+		application := app.New(cfg)
+		return application.WriteFolderReadmes(app.DocsOptions{
+			MirrorDir: docsMirrorDir,
+			DryRun:    docsDryRun,
+		})
+	},
+}
+
+func init() {
+	docsWriteCmd.Flags().StringVar(&docsMirrorDir, "mirror", "", "write READMEs into this directory, mirroring the analyzed tree")
+	docsWriteCmd.Flags().BoolVar(&docsDryRun, "dry-run", false, "show the diff of each README without writing")
+	docsCmd.AddCommand(docsWriteCmd)
+	rootCmd.AddCommand(docsCmd)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"archi/internal/app"
+)
+
+var (
+	promptsDumpDir   string
+	promptsDumpForce bool
+)
+
+var promptsCmd = &cobra.Command{
+	Use:   "prompts",
+	Short: "Inspect and customize the AI prompts",
+	Long: `Prompts are Go text/template files. The built-in prompts can be overridden
+per prompt with an inline template under "prompts" in the configuration, or with
+a <name>.tmpl file in the directory set by "promptDir".`,
+}
+
+var promptsDumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Write the built-in prompt templates to a directory",
+	Long: `Write the built-in prompt templates (file, folder, image, architecture,
+combine, repair, layout, evolution, security) to a directory for customization. Each template starts with a
+comment documenting its variables. Point promptDir at the directory to use them.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The built-in templates need no configuration, so that a broken
+		// custom template can always be replaced
+		return app.DumpPrompts(promptsDumpDir, promptsDumpForce)
+	},
+}
+
+var promptsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show where each prompt is loaded from",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfigFromGlobal()
+		if err != nil {
+			return err
+		}
+
+		application := app.New(cfg)
+		return application.ListPrompts()
+	},
+}
+
+func init() {
+	promptsDumpCmd.Flags().StringVar(&promptsDumpDir, "dir", "prompts", "directory to write the templates to")
+	promptsDumpCmd.Flags().BoolVar(&promptsDumpForce, "force", false, "overwrite existing templates")
+	promptsCmd.AddCommand(promptsDumpCmd)
+	promptsCmd.AddCommand(promptsListCmd)
+	rootCmd.AddCommand(promptsCmd)
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -12,7 +13,10 @@ import (
 )
 
 var (
-	cfgFile string
+	cfgFile        string
+	outputLanguage string
+	since          string
+	profile        string
 )
 
 var rootCmd = &cobra.Command{
@@ -22,6 +26,7 @@ var rootCmd = &cobra.Command{
 the structure and content of your projects. It can:
 
 - Analyze file contents and generate descriptions
+- Review files for security vulnerabilities (--profile security)
 - Create visual directory trees
 - Generate architectural recommendations
 - Estimate processing time for large projects
@@ -41,6 +46,9 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is ./config.yaml)")
+	rootCmd.Flags().StringVar(&since, "since", "", "analyze only the files changed since this git revision (branch, tag or commit) and merge them into the existing JSON output")
+	rootCmd.Flags().StringVar(&profile, "profile", "", "what files are analyzed for: description or security (overrides analysisProfile)")
+	rootCmd.PersistentFlags().StringVar(&outputLanguage, "language", "", "language of the generated descriptions and reports, e.g. en or fr (overrides outputLanguage)")
 }
 
 func initConfig() {
@@ -64,7 +72,7 @@ func initConfig() {
 
 func runAnalysis(cmd *cobra.Command, args []string) error {
 
-	cfg, err := config.LoadConfig(cfgFile)
+	cfg, err := loadConfigFromGlobal()
 	if err != nil {
 		return fmt.Errorf("error loading configuration: %w", err)
 	}
@@ -72,6 +80,14 @@ func runAnalysis(cmd *cobra.Command, args []string) error {
 	if cfgFile != "" || config.FileExists("config.yaml") {
 		fmt.Printf("📡 API endpoint: %s\n", cfg.APIBaseURL)
 	}
+	if profile != "" {
+		switch p := strings.ToLower(strings.TrimSpace(profile)); p {
+		case "description", "security":
+			cfg.AnalysisProfile = p
+		default:
+			return fmt.Errorf("--profile must be one of: description, security")
+		}
+	}
 
 	targetDir := "."
 	if len(args) > 0 {
@@ -81,9 +97,16 @@ func runAnalysis(cmd *cobra.Command, args []string) error {
 	application := app.New(cfg)
 
 	// Default behavior: run full analysis when no subcommand provided
-	return application.PerformFullAnalysis(targetDir)
+	return application.PerformFullAnalysis(targetDir, since)
 }
 
 func loadConfigFromGlobal() (*config.Config, error) {
-	return config.LoadConfig(cfgFile)
+	cfg, err := config.LoadConfig(cfgFile)
+	if err != nil {
+		return nil, err
+	}
+	if outputLanguage != "" {
+		cfg.OutputLanguage = outputLanguage
+	}
+	return cfg, nil
 }
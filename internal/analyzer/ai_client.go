@@ -11,6 +11,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"archi/internal/config"
@@ -19,11 +20,20 @@ import (
 )
 
 type AIClient struct {
-	config *config.Config
+	config  *config.Config
+	budget  *Budget
+	prompts *Prompts
+	context *ProjectContext
 }
 
-func NewAIClient(cfg *config.Config) *AIClient {
-	return &AIClient{config: cfg}
+// NewAIClient loads the prompt templates; a custom template that cannot be
+// read or parsed is an error rather than a silent fallback to the built-in one.
+func NewAIClient(cfg *config.Config) (*AIClient, error) {
+	prompts, err := LoadPrompts(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &AIClient{config: cfg, budget: NewBudget(cfg), prompts: prompts}, nil
 }
 
 func (c *AIClient) compressImage(imagePath string) ([]byte, error) {
@@ -90,45 +100,112 @@ func (c *AIClient) compressImage(imagePath string) ([]byte, error) {
 	}
 }
 
-func (c *AIClient) AnalyzeFileContent(content, filename string) (string, error) {
-	var model interface{}
+func (c *AIClient) fileModel() interface{} {
 	if len(c.config.FileAnalysisModels) > 0 {
-		model = c.config.FileAnalysisModels
-	} else {
-		model = c.config.FileAnalysisModel
+		return c.config.FileAnalysisModels
 	}
-	request := ChatRequest{
-		History: []ChatMessage{
-			{
-				Role:    "user",
-				Content: fmt.Sprintf("Please describe the content of this file named '%s' in 250 words maximum based on the following content (first 5000 characters):\n\n%s", filename, content),
-			},
-		},
-		Model: model,
+	return c.config.FileAnalysisModel
+}
+
+// SetProjectContext sets the domain context added to the prompts.
+func (c *AIClient) SetProjectContext(pc *ProjectContext) {
+	c.context = pc
+}
+
+// promptData returns the variables shared by every prompt, with the context
+// of the folder dir ("" for the project root).
+func (c *AIClient) promptData(dir string) PromptData {
+	if dir == "" && c.context != nil {
+		dir = c.context.root
+	}
+	return PromptData{
+		Language:       LanguageName(c.config.OutputLanguage),
+		ProjectContext: c.context.For(dir),
 	}
+}
 
-	jsonData, err := json.Marshal(request)
+// renderFitted renders a prompt, truncating data.Content to what the model's
+// budget leaves once the rest of the prompt is rendered. maxTokens further
+// caps the content when positive.
+func (c *AIClient) renderFitted(name string, model interface{}, data PromptData, maxTokens int) (string, error) {
+	content := data.Content
+	data.Content = ""
+	instructions, err := c.prompts.Render(name, data)
 	if err != nil {
-		return "", fmt.Errorf("error marshaling request: %v", err)
+		return "", err
+	}
+	tokens := c.budget.ContentTokens(model, instructions)
+	if maxTokens > 0 && maxTokens < tokens {
+		tokens = maxTokens
 	}
+	data.Content = TruncateTokens(content, tokens)
+	return c.prompts.Render(name, data)
+}
 
-	resp, err := http.Post(c.config.APIBaseURL+"/ask", "application/json", bytes.NewBuffer(jsonData))
+// FitFileContent truncates a file's content to the budget of the file
+// analysis prompt.
+func (c *AIClient) FitFileContent(content string, n *Node) string {
+	return TruncateTokens(content, c.FileContentBudget(n))
+}
+
+// FileContentBudget returns the tokens left for the content of a file in the
+// file prompt.
+func (c *AIClient) FileContentBudget(n *Node) int {
+	instructions, err := c.prompts.Render(PromptFile, c.fileData(n))
 	if err != nil {
-		return "", fmt.Errorf("error making request: %v", err)
+		instructions = ""
 	}
-	defer resp.Body.Close()
+	return c.budget.FileContentTokens(c.fileModel(), instructions)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+func (c *AIClient) AnalyzeFileContent(content string, n *Node) (string, error) {
+	data := c.fileData(n)
+	data.Content = content
+	prompt, err := c.renderFitted(PromptFile, c.fileModel(), data, c.config.Budget.MaxFileContentTokens)
+	if err != nil {
+		return "", err
 	}
+	return c.ask(c.fileModel(), prompt)
+}
 
-	var response ChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return "", fmt.Errorf("error decoding response: %v", err)
+// ReviewFileSecurity asks the file model for the vulnerabilities of a file,
+// given with line numbers so that the findings can point at lines.
+func (c *AIClient) ReviewFileSecurity(content string, n *Node) (*SecurityReview, error) {
+	data := c.fileData(n)
+	data.Content = numberLines(content, n.sourceLine)
+	prompt, err := c.renderFitted(PromptSecurity, c.fileModel(), data, c.config.Budget.MaxFileContentTokens)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.ask(c.fileModel(), prompt)
+	if err != nil {
+		return nil, err
 	}
 
-	return response.Response, nil
+	var review SecurityReview
+	if err := parseJSONResponse(response, &review); err != nil {
+		return nil, fmt.Errorf("error decoding security review: %v", err)
+	}
+	findings := review.Findings[:0]
+	for _, f := range review.Findings {
+		if strings.TrimSpace(f.Title) == "" {
+			continue
+		}
+		f.Severity = normalizeSeverity(f.Severity)
+		f.Source = "ai"
+		findings = append(findings, f)
+	}
+	review.Findings = findings
+	return &review, nil
+}
+
+// fileData returns the file prompt variables of a node, without its content.
+func (c *AIClient) fileData(n *Node) PromptData {
+	data := c.promptData(filepath.Dir(n.Path))
+	data.FileName, data.Path = n.Name, n.Path
+	data.Metadata = describeMetadata(n.Metadata)
+	data.Sampled = n.Sampled
+	return data
 }
 
 func (c *AIClient) AnalyzeImage(imagePath string) (string, error) {
@@ -168,99 +245,185 @@ func (c *AIClient) AnalyzeImage(imagePath string) (string, error) {
 		return "", fmt.Errorf("error decoding response: %v", err)
 	}
 
-	return response.Analysis, nil
+	return c.translateImageDescription(imagePath, response.Analysis)
+}
+
+// translateImageDescription translates the English answer of /analyze-image
+// into the output language, which the endpoint has no field for.
+func (c *AIClient) translateImageDescription(imagePath, description string) (string, error) {
+	data := PromptData{FileName: filepath.Base(imagePath), Path: imagePath, Content: description, Language: LanguageName(c.config.OutputLanguage)}
+	if description == "" || data.Language == "English" {
+		return description, nil
+	}
+	prompt, err := c.renderFitted(PromptImage, c.fileModel(), data, 0)
+	if err != nil {
+		return "", err
+	}
+	return c.ask(c.fileModel(), prompt)
 }
 
+// minFolderEntryTokens is the smallest share of the folder prompt given to
+// each entry, so large folders keep at least a short description per child.
+const minFolderEntryTokens = 24
+
 func (c *AIClient) AnalyzeFolderContent(node *Node) (string, error) {
 	if node.Type != "directory" || len(node.Children) == 0 {
 		return "", nil
 	}
 
-	childrenToAnalyze := node.Children
-	if len(childrenToAnalyze) > 20 {
-		childrenToAnalyze = childrenToAnalyze[:20]
+	var model interface{}
+	if len(c.config.FolderAnalysisModels) > 0 {
+		model = c.config.FolderAnalysisModels
+	} else {
+		model = c.config.FolderAnalysisModel
+	}
+
+	data := c.promptData(node.Path)
+	data.FileName, data.Path = node.Name, node.Path
+	instructions, err := c.prompts.Render(PromptFolder, data)
+	if err != nil {
+		return "", err
 	}
+	available := c.budget.ContentTokens(model, instructions)
 
-	contentBuilder := fmt.Sprintf("Folder: %s\nContents:\n", node.Name)
+	// Share the budget evenly between entries, then leave out the last
+	// entries if even the shortened ones do not fit
+	perEntry := available / len(node.Children)
+	if perEntry < minFolderEntryTokens {
+		perEntry = minFolderEntryTokens
+	}
 
-	for _, child := range childrenToAnalyze {
-		if child.Type == "directory" {
-			contentBuilder += fmt.Sprintf("📁 %s/ (directory", child.Name)
-			if len(child.Children) > 0 {
-				contentBuilder += fmt.Sprintf(" with %d items", len(child.Children))
-			}
-			contentBuilder += ")\n"
-		} else {
-			contentBuilder += fmt.Sprintf("📄 %s", child.Name)
-			if child.Description != "" {
-				desc := child.Description
-				if len(desc) > 100 {
-					desc = desc[:100] + "..."
-				}
-				contentBuilder += fmt.Sprintf(" - %s", desc)
-			}
-			contentBuilder += "\n"
+	used := 0
+	for i, child := range node.Children {
+		entry := PromptChild{Name: child.Name, IsDir: child.Type == "directory"}
+		if entry.IsDir {
+			entry.Items = len(child.Children)
+		} else if child.Description != "" {
+			desc := strings.Join(strings.Fields(child.Description), " ")
+			entry.Description = truncateInline(desc, perEntry-EstimateTokens(child.Name)-2)
+		}
+		tokens := EstimateTokens(entry.Name+entry.Description) + 4
+		if used+tokens > available {
+			data.OmittedChildren = len(node.Children) - i
+			break
 		}
+		used += tokens
+		data.Children = append(data.Children, entry)
 	}
 
-	if len(node.Children) > 20 {
-		contentBuilder += fmt.Sprintf("... and %d more items\n", len(node.Children)-20)
+	prompt, err := c.prompts.Render(PromptFolder, data)
+	if err != nil {
+		return "", err
 	}
+	return c.ask(model, prompt)
+}
 
-	prompt := fmt.Sprintf("Please describe this folder named '%s' in 250 words maximum based on its contents below:\n\n%s", node.Name, contentBuilder)
+// ArchitectureSignals holds the findings computed locally that are given to
+// every architecture prompt along with the project tree.
+type ArchitectureSignals struct {
+	// Hotspots lists the git history hotspots, one per line
+	Hotspots string
+	// NearDuplicates lists the largest near-duplicate clusters, one per line
+	NearDuplicates string
+}
 
-	var model interface{}
-	if len(c.config.FolderAnalysisModels) > 0 {
-		model = c.config.FolderAnalysisModels
-	} else {
-		model = c.config.FolderAnalysisModel
-	}
-	request := ChatRequest{
-		History: []ChatMessage{
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
-		Model: model,
+func (c *AIClient) architectureData(filename string, signals ArchitectureSignals) PromptData {
+	data := c.promptData("")
+	data.FileName, data.Schema = filename, architectureReportSchema
+	data.Hotspots, data.NearDuplicates = signals.Hotspots, signals.NearDuplicates
+	return data
+}
+
+// ArchitectureContentTokens returns the content budget of one architecture
+// prompt carrying the given signals, used to size the chunks and the
+// reduction groups.
+func (c *AIClient) ArchitectureContentTokens(signals ArchitectureSignals) int {
+	data := c.architectureData("chunk_0000.combined", signals)
+	instructions, err := c.prompts.Render(PromptArchitecture, data)
+	if err != nil {
+		instructions = ""
 	}
+	return c.budget.ContentTokens(c.architectureModel(), instructions)
+}
 
-	jsonData, err := json.Marshal(request)
+func (c *AIClient) AnalyzeArchitecture(content, filename string, signals ArchitectureSignals) (*ArchitectureReport, error) {
+	data := c.architectureData(filename, signals)
+	data.Content = content
+	prompt, err := c.renderFitted(PromptArchitecture, c.architectureModel(), data, 0)
 	if err != nil {
-		return "", fmt.Errorf("error marshaling request: %v", err)
+		return nil, err
 	}
+	return c.askArchitectureReport(prompt)
+}
 
-	resp, err := http.Post(c.config.APIBaseURL+"/ask", "application/json", bytes.NewBuffer(jsonData))
+func (c *AIClient) CombineArchitecturalAnalyses(reports []*ArchitectureReport) (*ArchitectureReport, error) {
+	analysesText := ""
+	for _, report := range reports {
+		data, err := json.Marshal(report)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling analysis: %v", err)
+		}
+		analysesText += string(data) + "\n\n---\n\n"
+	}
+
+	data := c.promptData("")
+	data.Schema, data.Content = architectureReportSchema, analysesText
+	prompt, err := c.renderFitted(PromptCombine, c.architectureModel(), data, 0)
 	if err != nil {
-		return "", fmt.Errorf("error making request: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return c.askArchitectureReport(prompt)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+// maxReportRepairs is the number of times a malformed architecture report is
+// sent back to the model for correction.
+const maxReportRepairs = 2
+
+// askArchitectureReport sends an architecture prompt and validates the JSON
+// answer, asking the model to repair it when it does not match the schema.
+func (c *AIClient) askArchitectureReport(prompt string) (*ArchitectureReport, error) {
+	model := c.architectureModel()
+	response, err := c.ask(model, prompt)
+	if err != nil {
+		return nil, err
 	}
 
-	var response ChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return "", fmt.Errorf("error decoding response: %v", err)
+	report, problems := ParseArchitectureReport(response)
+	for attempt := 0; len(problems) > 0 && attempt < maxReportRepairs; attempt++ {
+		data := c.promptData("")
+		data.Problems, data.Schema, data.Content = problems, architectureReportSchema, response
+		repairPrompt, err := c.renderFitted(PromptRepair, model, data, 0)
+		if err != nil {
+			return nil, err
+		}
+		response, err = c.ask(model, repairPrompt)
+		if err != nil {
+			return nil, err
+		}
+		report, problems = ParseArchitectureReport(response)
+	}
+	if report == nil {
+		return nil, fmt.Errorf("invalid architecture report: %s", strings.Join(problems, "; "))
 	}
 
-	return response.Response, nil
+	normalizeArchitectureReport(report)
+	return report, nil
 }
 
-func (c *AIClient) AnalyzeArchitecture(content, filename string) (string, error) {
-	var model interface{}
+func (c *AIClient) architectureModel() interface{} {
 	if len(c.config.ArchitectureAnalysisModels) > 0 {
-		model = c.config.ArchitectureAnalysisModels
-	} else {
-		model = c.config.ArchitectureAnalysisModel
+		return c.config.ArchitectureAnalysisModels
 	}
+	return c.config.ArchitectureAnalysisModel
+}
+
+// ask sends a single-message conversation to the /ask endpoint.
+func (c *AIClient) ask(model interface{}, prompt string) (string, error) {
 	request := ChatRequest{
 		History: []ChatMessage{
 			{
 				Role:    "user",
-				Content: fmt.Sprintf("Please analyze the software architecture of this project based on the provided file structure and descriptions from '%s'. Provide detailed recommendations for better architecture, including:\n\n1. Current architecture analysis\n2. Identified issues and anti-patterns\n3. Suggested improvements\n4. Recommended folder structure\n5. Best practices recommendations\n6. Technology stack optimization suggestions\n\nContent to analyze:\n%s", filename, content),
+				Content: prompt,
 			},
 		},
 		Model: model,
@@ -290,48 +453,55 @@ func (c *AIClient) AnalyzeArchitecture(content, filename string) (string, error)
 	return response.Response, nil
 }
 
-func (c *AIClient) CombineArchitecturalAnalyses(analyses []string) (string, error) {
-	analysesText := ""
-	for _, analysis := range analyses {
-		analysesText += analysis + "\n\n---\n\n"
-	}
-
-	var model interface{}
-	if len(c.config.ArchitectureAnalysisModels) > 0 {
-		model = c.config.ArchitectureAnalysisModels
-	} else {
-		model = c.config.ArchitectureAnalysisModel
-	}
-	request := ChatRequest{
-		History: []ChatMessage{
-			{
-				Role:    "user",
-				Content: fmt.Sprintf("Please combine and synthesize the following architectural analyses into a comprehensive final report. Create a cohesive architectural recommendation document that:\n\n1. Consolidates all findings into a unified analysis\n2. Removes redundancy while preserving important details\n3. Provides a clear executive summary\n4. Presents actionable recommendations in priority order\n5. Includes a proposed implementation roadmap\n\nAnalyses to combine:\n\n%s", analysesText),
-			},
-		},
-		Model: model,
+// GenerateTargetLayout asks the architecture model for the recommended folder
+// structure as JSON, assigning existing paths to target folders.
+func (c *AIClient) GenerateTargetLayout(paths, report string) (*TargetLayout, error) {
+	data := c.promptData("")
+	instructions, err := c.prompts.Render(PromptLayout, data)
+	if err != nil {
+		return nil, err
 	}
-
-	jsonData, err := json.Marshal(request)
+	// The report gets at most a third of the content budget, the paths the rest
+	available := c.budget.ContentTokens(c.architectureModel(), instructions)
+	data.Report = TruncateTokens(report, available/3)
+	data.Paths = TruncateTokens(paths, available-EstimateTokens(data.Report))
+	prompt, err := c.prompts.Render(PromptLayout, data)
 	if err != nil {
-		return "", fmt.Errorf("error marshaling request: %v", err)
+		return nil, err
 	}
 
-	resp, err := http.Post(c.config.APIBaseURL+"/ask", "application/json", bytes.NewBuffer(jsonData))
+	response, err := c.ask(c.architectureModel(), prompt)
 	if err != nil {
-		return "", fmt.Errorf("error making request: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	var layout TargetLayout
+	if err := parseJSONResponse(response, &layout); err != nil {
+		return nil, fmt.Errorf("error decoding target layout: %v", err)
 	}
+	return &layout, nil
+}
 
-	var response ChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return "", fmt.Errorf("error decoding response: %v", err)
+// SummarizeEvolution asks the architecture model to summarize a comparison
+// of two analyses, given as markdown.
+func (c *AIClient) SummarizeEvolution(comparison string) (string, error) {
+	data := c.promptData("")
+	data.Content = comparison
+	prompt, err := c.renderFitted(PromptEvolution, c.architectureModel(), data, 0)
+	if err != nil {
+		return "", err
 	}
+	return c.ask(c.architectureModel(), prompt)
+}
 
-	return response.Response, nil
+// parseJSONResponse decodes a JSON object from a model response, tolerating
+// markdown code fences and text around the object.
+func parseJSONResponse(response string, v interface{}) error {
+	text := strings.TrimSpace(response)
+	if start := strings.Index(text, "{"); start >= 0 {
+		if end := strings.LastIndex(text, "}"); end > start {
+			text = text[start : end+1]
+		}
+	}
+	return json.Unmarshal([]byte(text), v)
 }
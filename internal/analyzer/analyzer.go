@@ -14,15 +14,47 @@ import (
 type Analyzer struct {
 	config   *config.Config
 	aiClient *AIClient
+	secrets  *SecretScanner
+	pii      *PIIFilter
 }
 
 func New(cfg *config.Config) *Analyzer {
 	return &Analyzer{
-		config:   cfg,
-		aiClient: NewAIClient(cfg),
+		config:  cfg,
+		secrets: NewSecretScanner(cfg.Secrets),
 	}
 }
 
+// loadAIClient loads the prompt templates. Only the analyses that send them
+// need them, so that a broken custom template does not stop the other
+// commands, such as prompts dump, which restores the built-in ones.
+func (a *Analyzer) loadAIClient() error {
+	if a.aiClient != nil {
+		return nil
+	}
+	aiClient, err := NewAIClient(a.config)
+	if err != nil {
+		return err
+	}
+	a.aiClient = aiClient
+	return nil
+}
+
+// loadPIIFilter builds the personal data filter. It is only needed by the
+// analyses that extract file content, so that a names file that cannot be
+// read does not stop the other commands.
+func (a *Analyzer) loadPIIFilter() error {
+	if a.pii != nil {
+		return nil
+	}
+	pii, err := NewPIIFilter(a.config.PII)
+	if err != nil {
+		return err
+	}
+	a.pii = pii
+	return nil
+}
+
 func (a *Analyzer) PerformCountAnalysis(rootPath string) (*CountEstimation, error) {
 	estimation := &CountEstimation{
 		FileTypeStats: make([]FileTypeStats, 0),
@@ -122,23 +154,67 @@ func (a *Analyzer) calculateFolderStats(folderPath, folderName string) (*FolderS
 }
 
 func (a *Analyzer) PerformFullAnalysis(rootPath string, mode string) (*Node, error) {
-	m := strings.ToLower(strings.TrimSpace(mode))
-	onlyFolders := m == "folder-only"
-	noContent := m == "description-only"
+	onlyFolders, noContent := parseMode(mode)
 	// Normalize the root path to avoid trailing-slash mismatches when linking parent/child nodes
 	rootPath = filepath.Clean(rootPath)
-	nodes := make(map[string]*Node)
-	var rootNode *Node
-	currentFile := 0
+	if err := a.loadAIClient(); err != nil {
+		return nil, err
+	}
+	if err := a.loadPIIFilter(); err != nil {
+		return nil, err
+	}
 
-	var totalFiles int
-	filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
-		if err == nil && !info.IsDir() && (!onlyFolders) {
-			totalFiles++
+	projectContext, err := LoadProjectContext(a.config, rootPath)
+	if err != nil {
+		return nil, err
+	}
+	a.aiClient.SetProjectContext(projectContext)
+
+	rootNode, fileNodes, err := a.buildTree(rootPath, onlyFolders)
+	if err != nil {
+		return nil, err
+	}
+
+	a.collectMetadata(rootPath, fileNodes)
+	a.collectHistory(rootPath, rootNode, fileNodes)
+	aggregateMetadata(rootNode)
+	a.detectLicenses(rootNode, fileNodes)
+
+	if !onlyFolders {
+		unique := a.markDuplicates(fileNodes)
+		a.findNearDuplicates(unique)
+		a.analyzeFiles(unique, noContent)
+		shareDuplicateDescriptions(fileNodes)
+	}
+
+	var folderNodes []*Node
+	var collect func(n *Node)
+	collect = func(n *Node) {
+		if n.Type == "directory" {
+			folderNodes = append(folderNodes, n)
 		}
-		return nil
-	})
+		for _, ch := range n.Children {
+			collect(ch)
+		}
+	}
+	collect(rootNode)
+	a.analyzeFolders(folderNodes)
+
+	return rootNode, nil
+}
+
+// parseMode returns whether the analysis mode skips files ("folder-only") and
+// whether it leaves file contents out of the output ("description-only").
+func parseMode(mode string) (onlyFolders, noContent bool) {
+	m := strings.ToLower(strings.TrimSpace(mode))
+	return m == "folder-only", m == "description-only"
+}
 
+// buildTree walks rootPath into a tree of nodes, with their stat metadata,
+// and returns it along with its file nodes in walk order.
+func (a *Analyzer) buildTree(rootPath string, onlyFolders bool) (*Node, []*Node, error) {
+	nodes := make(map[string]*Node)
+	var rootNode *Node
 	var fileNodes []*Node
 	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -162,6 +238,9 @@ func (a *Analyzer) PerformFullAnalysis(rootPath string, mode string) (*Node, err
 		} else {
 			node.Type = "file"
 		}
+		if a.config.Metadata.Enabled {
+			node.Metadata = statMetadata(info)
+		}
 
 		if node.Type == "file" {
 			fileNodes = append(fileNodes, node)
@@ -180,90 +259,91 @@ func (a *Analyzer) PerformFullAnalysis(rootPath string, mode string) (*Node, err
 
 		return nil
 	})
-
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	return rootNode, fileNodes, nil
+}
 
-	if !onlyFolders {
-		fmt.Printf("\n\n📦 Analyzing files in batches of %d...\n", a.config.BatchSize)
-		total := len(fileNodes)
-		currentFile = 0
-		a.printProgressBar(currentFile, total, "📄 Processing files:")
-		for i := 0; i < total; i += a.config.BatchSize {
-			end := i + a.config.BatchSize
-			if end > total {
-				end = total
-			}
+// analyzeFiles describes the files in batches of BatchSize concurrent requests.
+func (a *Analyzer) analyzeFiles(fileNodes []*Node, noContent bool) {
+	fmt.Printf("\n\n📦 Analyzing files in batches of %d...\n", a.config.BatchSize)
+	total := len(fileNodes)
+	currentFile := 0
+	security := IsSecurityProfile(a.config.AnalysisProfile)
+	a.printProgressBar(currentFile, total, "📄 Processing files:")
+	for i := 0; i < total; i += a.config.BatchSize {
+		end := i + a.config.BatchSize
+		if end > total {
+			end = total
+		}
 
-			batch := fileNodes[i:end]
-			var wg sync.WaitGroup
-			wg.Add(len(batch))
+		batch := fileNodes[i:end]
+		var wg sync.WaitGroup
+		wg.Add(len(batch))
 
-			for _, n := range batch {
-				n := n
-				path := n.Path
-				info, err := os.Stat(path)
-				if err != nil {
-					fmt.Printf("\n⚠️  Skipping file %s: %v\n", path, err)
-					wg.Done()
-					continue
-				}
+		for _, n := range batch {
+			n := n
+			path := n.Path
+			info, err := os.Stat(path)
+			if err != nil {
+				fmt.Printf("\n⚠️  Skipping file %s: %v\n", path, err)
+				wg.Done()
+				continue
+			}
 
-				go func() {
-					defer wg.Done()
-					ext := strings.ToLower(filepath.Ext(info.Name()))
-					switch ext {
-					case ".png", ".jpg", ".jpeg", ".gif", ".bmp", ".webp":
-						desc, err := a.aiClient.AnalyzeImage(path)
-						if err != nil {
-							fmt.Printf("\n⚠️  Error analyzing image %s: %v\n", path, err)
-							return
-						}
-						n.Description = fmt.Sprintf("Image analysis: %s", desc)
-					default:
-						content, err := a.extractFileContent(path, info)
-						if err != nil || content == "" {
-							return
-						}
-						if len(content) > 5000 {
-							content = content[:5000]
-						}
-						if !noContent {
-							n.Content = content
-						}
-						desc, err := a.aiClient.AnalyzeFileContent(content, info.Name())
-						if err != nil {
-							fmt.Printf("\n⚠️  Error analyzing file %s: %v\n", path, err)
-							return
+			go func() {
+				defer wg.Done()
+				ext := strings.ToLower(filepath.Ext(info.Name()))
+				switch ext {
+				case ".png", ".jpg", ".jpeg", ".gif", ".bmp", ".webp":
+					desc, err := a.aiClient.AnalyzeImage(path)
+					if err != nil {
+						fmt.Printf("\n⚠️  Error analyzing image %s: %v\n", path, err)
+						return
+					}
+					n.Description = fmt.Sprintf("Image analysis: %s", desc)
+				default:
+					content, err := a.extractFileContent(n, info)
+					if err != nil || content == "" {
+						return
+					}
+					content = a.redactSecrets(n, content)
+					if security {
+						n.Security = scanFileSecurity(n, content)
+					}
+					content = a.aiClient.FitFileContent(content, n)
+					if !noContent {
+						n.Content = content
+					}
+					if security {
+						if err := a.reviewFileSecurity(n, content); err != nil {
+							fmt.Printf("\n⚠️  Error reviewing file %s: %v\n", path, err)
 						}
-						n.Description = desc
+						return
 					}
-				}()
-			}
-			wg.Wait()
-			currentFile += len(batch)
-			a.printProgressBar(currentFile, total, "📄 Processing files:")
-			time.Sleep(a.config.RequestDelay)
+					desc, err := a.aiClient.AnalyzeFileContent(content, n)
+					if err != nil {
+						fmt.Printf("\n⚠️  Error analyzing file %s: %v\n", path, err)
+						return
+					}
+					n.Description = desc
+				}
+			}()
 		}
+		wg.Wait()
+		currentFile += len(batch)
+		a.printProgressBar(currentFile, total, "📄 Processing files:")
+		time.Sleep(a.config.RequestDelay)
 	}
+}
 
+// analyzeFolders describes the folders in batches of BatchSize concurrent requests.
+func (a *Analyzer) analyzeFolders(folderNodes []*Node) {
 	fmt.Printf("\n\n🗂️  Starting folder description generation...\n")
-	totalFolders := a.countFolders(rootNode)
+	totalFolders := len(folderNodes)
 	fmt.Printf("   Found %d folders to analyze\n", totalFolders)
 
-	var folderNodes []*Node
-	var collect func(n *Node)
-	collect = func(n *Node) {
-		if n.Type == "directory" {
-			folderNodes = append(folderNodes, n)
-		}
-		for _, ch := range n.Children {
-			collect(ch)
-		}
-	}
-	collect(rootNode)
-
 	currentFolder := 0
 	a.printProgressBar(currentFolder, totalFolders, "📁 Processing folders:")
 	for i := 0; i < len(folderNodes); i += a.config.BatchSize {
@@ -291,11 +371,37 @@ func (a *Analyzer) PerformFullAnalysis(rootPath string, mode string) (*Node, err
 		a.printProgressBar(currentFolder, totalFolders, "📁 Processing folders:")
 		time.Sleep(a.config.RequestDelay)
 	}
+}
 
-	return rootNode, nil
+// textExtensions are the files read as plain text. Above maxFileSize, they
+// are sampled instead.
+var textExtensions = map[string]bool{
+	".txt": true, ".md": true, ".go": true, ".js": true, ".py": true, ".java": true, ".c": true, ".cpp": true,
+	".h": true, ".hpp": true, ".css": true, ".html": true, ".xml": true, ".json": true, ".yaml": true, ".yml": true,
+	".toml": true, ".ini": true, ".cfg": true, ".conf": true, ".log": true, ".csv": true, ".tsv": true, ".jsonl": true,
+}
+
+// extractFileContent returns the text of a file with its personal data
+// redacted, recorded on the node. Text files larger than maxFileSize are
+// sampled, and documents already parsed by the near-duplicate search are not
+// parsed again.
+func (a *Analyzer) extractFileContent(n *Node, info os.FileInfo) (string, error) {
+	var content string
+	var err error
+	if n.text != "" {
+		content, n.text = n.text, ""
+	} else if a.config.Sampling.Enabled && info.Size() > a.config.MaxFileSize && textExtensions[strings.ToLower(filepath.Ext(info.Name()))] {
+		content, err = a.sampleFileContent(n)
+	} else {
+		content, err = a.readFileContent(n.Path, info)
+	}
+	if err != nil || content == "" {
+		return content, err
+	}
+	return a.redactPII(n, content), nil
 }
 
-func (a *Analyzer) extractFileContent(path string, info os.FileInfo) (string, error) {
+func (a *Analyzer) readFileContent(path string, info os.FileInfo) (string, error) {
 	ext := strings.ToLower(filepath.Ext(info.Name()))
 
 	isImageFile := ext == ".png" || ext == ".jpg" || ext == ".jpeg" || ext == ".gif" || ext == ".bmp" || ext == ".webp"
@@ -304,14 +410,15 @@ func (a *Analyzer) extractFileContent(path string, info os.FileInfo) (string, er
 		return "", fmt.Errorf("file too large")
 	}
 
-	switch ext {
-	case ".txt", ".md", ".go", ".js", ".py", ".java", ".c", ".cpp", ".h", ".hpp", ".css", ".html", ".xml", ".json", ".yaml", ".yml", ".toml", ".ini", ".cfg", ".conf":
+	if textExtensions[ext] {
 		content, err := os.ReadFile(path)
 		if err != nil {
 			return "", err
 		}
 		return string(content), nil
+	}
 
+	switch ext {
 	case ".docx":
 		return ReadDocx(path)
 
@@ -321,6 +428,9 @@ func (a *Analyzer) extractFileContent(path string, info os.FileInfo) (string, er
 	case ".pdf":
 		return ReadPdf(path)
 
+	case ".eml":
+		return ReadEml(path)
+
 	case ".png", ".jpg", ".jpeg", ".gif", ".bmp", ".webp":
 		// We don't analyze images here to keep text-only content extraction.
 		// Image analysis is triggered via AI client when appropriate elsewhere.
@@ -0,0 +1,209 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// architectureReportSchema is included in the architecture prompts so the
+// models answer with a parseable ArchitectureReport.
+const architectureReportSchema = `{
+  "summary": "executive summary (string)",
+  "currentArchitecture": "analysis of the current architecture (string)",
+  "findings": [
+    {
+      "title": "short title (string)",
+      "category": "structure | coupling | naming | duplication | testing | security | performance | tooling | documentation | other",
+      "severity": "critical | high | medium | low | info",
+      "affectedPaths": ["relative/path", "..."],
+      "description": "what the issue or anti-pattern is (string)",
+      "recommendation": "what to do about it (string)",
+      "effort": "small | medium | large",
+      "priority": 1
+    }
+  ],
+  "recommendedStructure": "recommended folder structure as an indented tree (string)",
+  "roadmap": ["ordered implementation steps (string)", "..."]
+}`
+
+var (
+	findingSeverities = []string{"critical", "high", "medium", "low", "info"}
+	findingEfforts    = []string{"small", "medium", "large"}
+)
+
+// ArchitectureReport is the structured result of the architecture step,
+// written next to the markdown report.
+type ArchitectureReport struct {
+	Summary              string    `json:"summary"`
+	CurrentArchitecture  string    `json:"currentArchitecture"`
+	Findings             []Finding `json:"findings"`
+	RecommendedStructure string    `json:"recommendedStructure,omitempty"`
+	Roadmap              []string  `json:"roadmap,omitempty"`
+	GeneratedAt          string    `json:"generatedAt,omitempty"`
+}
+
+type Finding struct {
+	Title          string   `json:"title"`
+	Category       string   `json:"category,omitempty"`
+	Severity       string   `json:"severity"`
+	AffectedPaths  []string `json:"affectedPaths,omitempty"`
+	Description    string   `json:"description,omitempty"`
+	Recommendation string   `json:"recommendation"`
+	Effort         string   `json:"effort"`
+	// Priority orders the findings, 1 being the most urgent
+	Priority int `json:"priority"`
+}
+
+func severityRank(s string) int {
+	for i, v := range findingSeverities {
+		if v == s {
+			return i
+		}
+	}
+	return len(findingSeverities)
+}
+
+func oneOf(value string, allowed []string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseArchitectureReport decodes a model response and validates it against
+// the schema. Enumerations are normalized to lower case before checking.
+func ParseArchitectureReport(response string) (*ArchitectureReport, []string) {
+	var report ArchitectureReport
+	if err := parseJSONResponse(response, &report); err != nil {
+		return nil, []string{fmt.Sprintf("response is not valid JSON: %v", err)}
+	}
+
+	var problems []string
+	if strings.TrimSpace(report.Summary) == "" {
+		problems = append(problems, "summary is empty")
+	}
+	for i := range report.Findings {
+		f := &report.Findings[i]
+		f.Severity = strings.ToLower(strings.TrimSpace(f.Severity))
+		f.Effort = strings.ToLower(strings.TrimSpace(f.Effort))
+		f.Category = strings.ToLower(strings.TrimSpace(f.Category))
+		if strings.TrimSpace(f.Title) == "" {
+			problems = append(problems, fmt.Sprintf("findings[%d].title is empty", i))
+		}
+		if strings.TrimSpace(f.Recommendation) == "" {
+			problems = append(problems, fmt.Sprintf("findings[%d].recommendation is empty", i))
+		}
+		if !oneOf(f.Severity, findingSeverities) {
+			problems = append(problems, fmt.Sprintf("findings[%d].severity %q must be one of %s", i, f.Severity, strings.Join(findingSeverities, ", ")))
+		}
+		if !oneOf(f.Effort, findingEfforts) {
+			problems = append(problems, fmt.Sprintf("findings[%d].effort %q must be one of %s", i, f.Effort, strings.Join(findingEfforts, ", ")))
+		}
+		if f.Priority < 1 {
+			problems = append(problems, fmt.Sprintf("findings[%d].priority must be >= 1", i))
+		}
+	}
+	return &report, problems
+}
+
+// normalizeArchitectureReport fills in defaults for fields the model still
+// got wrong after repair attempts, so the report remains usable.
+func normalizeArchitectureReport(report *ArchitectureReport) {
+	for i := range report.Findings {
+		f := &report.Findings[i]
+		if !oneOf(f.Severity, findingSeverities) {
+			f.Severity = "medium"
+		}
+		if !oneOf(f.Effort, findingEfforts) {
+			f.Effort = "medium"
+		}
+		if f.Priority < 1 {
+			f.Priority = len(report.Findings)
+		}
+		if strings.TrimSpace(f.Title) == "" {
+			f.Title = fmt.Sprintf("Finding %d", i+1)
+		}
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return severityRank(a.Severity) < severityRank(b.Severity)
+	})
+}
+
+// RenderArchitectureMarkdown renders the markdown report from the structured
+// report, with headings in the given output language.
+func RenderArchitectureMarkdown(report *ArchitectureReport, lang string) string {
+	var md strings.Builder
+
+	md.WriteString(tr(lang, "# Architecture Report") + "\n\n")
+	if report.GeneratedAt != "" {
+		md.WriteString(fmt.Sprintf(tr(lang, "**Generated on:** %s")+"\n\n", report.GeneratedAt))
+	}
+
+	md.WriteString(tr(lang, "## Executive Summary") + "\n\n")
+	md.WriteString(strings.TrimSpace(report.Summary) + "\n\n")
+
+	if strings.TrimSpace(report.CurrentArchitecture) != "" {
+		md.WriteString(tr(lang, "## Current Architecture") + "\n\n")
+		md.WriteString(strings.TrimSpace(report.CurrentArchitecture) + "\n\n")
+	}
+
+	if len(report.Findings) > 0 {
+		md.WriteString(tr(lang, "## Findings") + "\n\n")
+		md.WriteString(tr(lang, "| # | Priority | Severity | Finding | Effort |") + "\n")
+		md.WriteString("|---|----------|----------|---------|--------|\n")
+		for i, f := range report.Findings {
+			md.WriteString(fmt.Sprintf("| %d | %d | %s | %s | %s |\n", i+1, f.Priority, f.Severity, escapeTableCell(f.Title), f.Effort))
+		}
+		md.WriteString("\n")
+
+		for i, f := range report.Findings {
+			md.WriteString(fmt.Sprintf("### %d. %s\n\n", i+1, f.Title))
+			meta := fmt.Sprintf("- **%s:** %s · **%s:** %s · **%s:** %d",
+				tr(lang, "Severity"), f.Severity, tr(lang, "Effort"), f.Effort, tr(lang, "Priority"), f.Priority)
+			if f.Category != "" {
+				meta += fmt.Sprintf(" · **%s:** %s", tr(lang, "Category"), f.Category)
+			}
+			md.WriteString(meta + "\n")
+			if len(f.AffectedPaths) > 0 {
+				md.WriteString("- **" + tr(lang, "Affected paths") + ":** `" + strings.Join(f.AffectedPaths, "`, `") + "`\n")
+			}
+			md.WriteString("\n")
+			if desc := strings.TrimSpace(f.Description); desc != "" {
+				md.WriteString(desc + "\n\n")
+			}
+			md.WriteString(fmt.Sprintf("**%s:** %s\n\n", tr(lang, "Recommendation"), strings.TrimSpace(f.Recommendation)))
+		}
+	}
+
+	if strings.TrimSpace(report.RecommendedStructure) != "" {
+		md.WriteString(tr(lang, "## Recommended Folder Structure") + "\n\n")
+		md.WriteString("```\n" + strings.Trim(report.RecommendedStructure, "\n") + "\n```\n\n")
+	}
+
+	if len(report.Roadmap) > 0 {
+		md.WriteString(tr(lang, "## Implementation Roadmap") + "\n\n")
+		for i, step := range report.Roadmap {
+			md.WriteString(fmt.Sprintf("%d. %s\n", i+1, strings.TrimSpace(step)))
+		}
+		md.WriteString("\n")
+	}
+
+	return md.String()
+}
+
+// MarshalArchitectureReport stamps the report and encodes it as indented JSON.
+func MarshalArchitectureReport(report *ArchitectureReport) ([]byte, error) {
+	if report.GeneratedAt == "" {
+		report.GeneratedAt = time.Now().Format("2006-01-02 15:04:05")
+	}
+	return json.MarshalIndent(report, "", "  ")
+}
@@ -0,0 +1,152 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"archi/internal/config"
+)
+
+// minContentTokens is the smallest content allowance handed out, so a
+// misconfigured context window still leaves the model something to read.
+const minContentTokens = 256
+
+// Budget allocates the context window of the configured models between the
+// prompt instructions, the content and the model's answer. Sizes are in
+// estimated tokens.
+type Budget struct {
+	cfg config.BudgetConfig
+}
+
+func NewBudget(cfg *config.Config) *Budget {
+	return &Budget{cfg: cfg.Budget}
+}
+
+// EstimateTokens approximates the token count of s: about four ASCII
+// characters per token, and one token per other character. The estimate errs
+// on the high side for non-English text.
+func EstimateTokens(s string) int {
+	ascii, other := 0, 0
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// ContextWindow returns the context window of a model as accepted by
+// ChatRequest.Model. For a list of provider models, the smallest window is
+// used since any of them may serve the request.
+func (b *Budget) ContextWindow(model interface{}) int {
+	lookup := func(name string) int {
+		if w, ok := b.cfg.ContextWindows[strings.ToLower(strings.TrimSpace(name))]; ok && w > 0 {
+			return w
+		}
+		return b.cfg.DefaultContextWindow
+	}
+	switch m := model.(type) {
+	case string:
+		return lookup(m)
+	case []config.ProviderModel:
+		window := 0
+		for _, pm := range m {
+			if w := lookup(pm.Model); window == 0 || w < window {
+				window = w
+			}
+		}
+		if window > 0 {
+			return window
+		}
+	}
+	return b.cfg.DefaultContextWindow
+}
+
+// ContentTokens returns the tokens left for content once the instructions and
+// the answer reserve are taken out of the model's context window.
+func (b *Budget) ContentTokens(model interface{}, instructions string) int {
+	available := b.ContextWindow(model) - b.cfg.ResponseTokens - EstimateTokens(instructions)
+	if available < minContentTokens {
+		return minContentTokens
+	}
+	return available
+}
+
+// FileContentTokens returns the allowance for a file's content, capped by
+// budget.maxFileContentTokens when set.
+func (b *Budget) FileContentTokens(model interface{}, instructions string) int {
+	tokens := b.ContentTokens(model, instructions)
+	if b.cfg.MaxFileContentTokens > 0 && b.cfg.MaxFileContentTokens < tokens {
+		tokens = b.cfg.MaxFileContentTokens
+	}
+	return tokens
+}
+
+// TruncateTokens cuts s to at most maxTokens estimated tokens. The cut falls
+// on a line boundary when one is available in the second half of the kept
+// text, otherwise on a rune boundary, and is followed by an explicit marker
+// counting the omitted lines. When no content fits, the marker is returned
+// alone, shortened to maxTokens if needed.
+func TruncateTokens(s string, maxTokens int) string {
+	if EstimateTokens(s) <= maxTokens {
+		return s
+	}
+	totalLines := strings.Count(s, "\n") + 1
+	// Reserve room for the marker; its length barely varies with the count
+	keep := maxTokens - EstimateTokens(truncationMarker(totalLines, totalLines))
+	if keep <= 0 {
+		// No content fits next to the marker: the marker alone still tells
+		// that everything was left out
+		if maxTokens <= 0 {
+			return ""
+		}
+		return truncateInline(strings.TrimPrefix(truncationMarker(totalLines, totalLines), "\n"), maxTokens)
+	}
+
+	ascii, other, cut := 0, 0, 0
+	for i, r := range s {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+		if (ascii+3)/4+other > keep {
+			break
+		}
+		cut = i + utf8.RuneLen(r)
+	}
+	kept := s[:cut]
+	// Lines cut in the middle count as omitted
+	keptLines := strings.Count(kept, "\n")
+	if nl := strings.LastIndex(kept, "\n"); nl >= 0 && nl >= len(kept)/2 {
+		kept = kept[:nl]
+	}
+	return kept + truncationMarker(totalLines-keptLines, totalLines)
+}
+
+func truncationMarker(omitted, total int) string {
+	return fmt.Sprintf("\n[... truncated: %d of %d lines omitted]", omitted, total)
+}
+
+// truncateInline shortens a single-line text to maxTokens, ending it with an
+// ellipsis.
+func truncateInline(s string, maxTokens int) string {
+	if EstimateTokens(s) <= maxTokens {
+		return s
+	}
+	ascii, other := 0, 0
+	for i, r := range s {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+		if (ascii+3)/4+other > maxTokens-1 {
+			return strings.TrimSpace(s[:i]) + "…"
+		}
+	}
+	return s
+}
@@ -0,0 +1,39 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTruncateTokens(t *testing.T) {
+	long := strings.Repeat("some line of text\n", 40)
+	tests := []struct {
+		name      string
+		maxTokens int
+		want      string
+	}{
+		{"fits", 1000, long},
+		{"marker alone", 10, "[... truncated: 41 of 41 lines omitted]"},
+		{"shortened marker", 5, "[... truncated:…"},
+		{"no room", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TruncateTokens(long, tt.maxTokens)
+			if got != tt.want {
+				t.Errorf("TruncateTokens(%d) = %q, want %q", tt.maxTokens, got, tt.want)
+			}
+			if EstimateTokens(got) > max(tt.maxTokens, 0) {
+				t.Errorf("TruncateTokens(%d) has %d tokens", tt.maxTokens, EstimateTokens(got))
+			}
+		})
+	}
+
+	got := TruncateTokens(long, 60)
+	if !strings.HasSuffix(got, " lines omitted]") || !strings.HasPrefix(got, "some line of text\n") {
+		t.Errorf("TruncateTokens(60) = %q, want content followed by the marker", got)
+	}
+	if EstimateTokens(got) > 60 {
+		t.Errorf("TruncateTokens(60) has %d tokens", EstimateTokens(got))
+	}
+}
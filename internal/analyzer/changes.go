@@ -0,0 +1,284 @@
+package analyzer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"archi/internal/gitrepo"
+)
+
+// Change statuses recorded in Node.ChangeStatus by --since analyses.
+const (
+	StatusAdded     = "added"
+	StatusModified  = "modified"
+	StatusRenamed   = "renamed"
+	StatusDeleted   = "deleted"
+	StatusUnchanged = "unchanged"
+)
+
+// PerformChangedAnalysis analyzes only what changed since the git revision
+// since: the added and modified files, and the folders containing any change.
+// Unchanged nodes, and renamed files whose content is identical, keep the
+// description of the previous analysis when one is given. Files deleted since
+// the revision are kept as nodes with the deleted status.
+func (a *Analyzer) PerformChangedAnalysis(rootPath, mode, since string, previous *Node) (*Node, *ChangeSummary, error) {
+	onlyFolders, noContent := parseMode(mode)
+	rootPath = filepath.Clean(rootPath)
+	if err := a.loadAIClient(); err != nil {
+		return nil, nil, err
+	}
+	if err := a.loadPIIFilter(); err != nil {
+		return nil, nil, err
+	}
+
+	repo, err := gitrepo.Open(rootPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("--since needs a git repository: %w", err)
+	}
+	from, err := repo.Resolve(since)
+	if err != nil {
+		return nil, nil, err
+	}
+	prefix, _ := repo.RelPath(rootPath)
+	changes, err := repo.DiffWorkTree(from, prefix)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error comparing with %s: %w", since, err)
+	}
+
+	summary := &ChangeSummary{Since: since, Commit: from.String()}
+	nodePath := func(p string) string { return repoNodePath(rootPath, prefix, p) }
+	status := make(map[string]string)
+	renamedFrom := make(map[string]string)
+	changedDirs := make(map[string]bool)
+	var deleted []string
+	markParents := func(path string) {
+		for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+			changedDirs[dir] = true
+			if dir == rootPath || dir == filepath.Dir(dir) {
+				return
+			}
+		}
+	}
+	for _, ch := range changes {
+		path := nodePath(ch.Path)
+		markParents(path)
+		switch ch.Action {
+		case gitrepo.Added:
+			summary.Added++
+		case gitrepo.Modified:
+			summary.Modified++
+		case gitrepo.Renamed:
+			summary.Renamed++
+			renamedFrom[path] = nodePath(ch.OldPath)
+			markParents(renamedFrom[path])
+		case gitrepo.Deleted:
+			summary.Deleted++
+			deleted = append(deleted, path)
+		}
+		status[path] = string(ch.Action)
+	}
+	fmt.Printf("🔀 Changes since %s (%s): %d added, %d modified, %d renamed, %d deleted\n",
+		since, from.String()[:8], summary.Added, summary.Modified, summary.Renamed, summary.Deleted)
+
+	projectContext, err := LoadProjectContext(a.config, rootPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	a.aiClient.SetProjectContext(projectContext)
+
+	rootNode, fileNodes, err := a.buildTree(rootPath, onlyFolders)
+	if err != nil {
+		return nil, nil, err
+	}
+	a.collectMetadata(rootPath, fileNodes)
+	a.collectHistory(rootPath, rootNode, fileNodes)
+	aggregateMetadata(rootNode)
+	a.detectLicenses(rootNode, fileNodes)
+
+	prev := indexPrevious(previous)
+	reuse := func(n *Node, path string) {
+		if p := prev[relativeTo(rootPath, path)]; p != nil && p.Type == n.Type {
+			n.Description = p.Description
+			n.Security = p.Security
+			n.Secrets = p.Secrets
+			n.PII = p.PII
+			n.Sampled = p.Sampled
+			if !noContent {
+				n.Content = p.Content
+			}
+		}
+	}
+
+	var changedFiles []*Node
+	for _, n := range fileNodes {
+		n.ChangeStatus = status[n.Path]
+		switch n.ChangeStatus {
+		case StatusAdded, StatusModified:
+			changedFiles = append(changedFiles, n)
+		case StatusRenamed:
+			reuse(n, renamedFrom[n.Path])
+			if n.Description == "" {
+				changedFiles = append(changedFiles, n)
+			}
+		default:
+			n.ChangeStatus = StatusUnchanged
+			reuse(n, n.Path)
+		}
+	}
+
+	var changedFolders []*Node
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		if n.Type != "directory" {
+			return
+		}
+		if changedDirs[n.Path] {
+			n.ChangeStatus = StatusModified
+			changedFolders = append(changedFolders, n)
+		} else {
+			n.ChangeStatus = StatusUnchanged
+			reuse(n, n.Path)
+		}
+		for _, ch := range n.Children {
+			visit(ch)
+		}
+	}
+	visit(rootNode)
+	markAddedFolders(rootNode)
+
+	a.findNearDuplicates(a.markDuplicates(fileNodes))
+	changedFiles = filesToAnalyze(changedFiles, fileNodes)
+	if !onlyFolders && len(changedFiles) > 0 {
+		a.analyzeFiles(changedFiles, noContent)
+	}
+	shareDuplicateDescriptions(fileNodes)
+	if len(changedFolders) > 0 {
+		a.analyzeFolders(changedFolders)
+	}
+
+	if !onlyFolders {
+		addDeletedNodes(rootNode, rootPath, deleted, prev)
+	}
+	return rootNode, summary, nil
+}
+
+// indexPrevious maps the nodes of a previous analysis by their path relative
+// to its root, so that outputs produced from another working directory match.
+func indexPrevious(root *Node) map[string]*Node {
+	index := make(map[string]*Node)
+	if root == nil {
+		return index
+	}
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		index[relativeTo(root.Path, n.Path)] = n
+		for _, ch := range n.Children {
+			walk(ch)
+		}
+	}
+	walk(root)
+	return index
+}
+
+func relativeTo(root, path string) string {
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(path))
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// markAddedFolders turns the status of changed folders whose files were all
+// added into added. It reports whether every file below n was added.
+func markAddedFolders(n *Node) bool {
+	if n.Type != "directory" {
+		return n.ChangeStatus == StatusAdded
+	}
+	allAdded := len(n.Children) > 0
+	for _, ch := range n.Children {
+		if !markAddedFolders(ch) {
+			allAdded = false
+		}
+	}
+	if allAdded && n.ChangeStatus == StatusModified {
+		n.ChangeStatus = StatusAdded
+	}
+	return allAdded
+}
+
+// addDeletedNodes inserts the deleted files into the tree, recreating the
+// deleted folders that held them. They keep their previous description.
+func addDeletedNodes(root *Node, rootPath string, deleted []string, prev map[string]*Node) {
+	nodes := make(map[string]*Node)
+	var index func(n *Node)
+	index = func(n *Node) {
+		nodes[n.Path] = n
+		for _, ch := range n.Children {
+			index(ch)
+		}
+	}
+	index(root)
+
+	var ensureDir func(path string) *Node
+	ensureDir = func(path string) *Node {
+		if n, ok := nodes[path]; ok {
+			return n
+		}
+		parent := ensureDir(filepath.Dir(path))
+		n := &Node{Path: path, Name: filepath.Base(path), Type: "directory", ChangeStatus: StatusDeleted}
+		if p := prev[relativeTo(rootPath, path)]; p != nil {
+			n.Description = p.Description
+		}
+		parent.Children = append(parent.Children, n)
+		nodes[path] = n
+		return n
+	}
+
+	for _, path := range deleted {
+		if _, exists := nodes[path]; exists {
+			continue
+		}
+		n := &Node{Path: path, Name: filepath.Base(path), Type: "file", ChangeStatus: StatusDeleted}
+		if p := prev[relativeTo(rootPath, path)]; p != nil {
+			n.Description = p.Description
+		}
+		parent := ensureDir(filepath.Dir(path))
+		parent.Children = append(parent.Children, n)
+		nodes[path] = n
+	}
+}
+
+// generateChangesSection lists the changed files of a --since analysis with
+// their descriptions.
+func generateChangesSection(output *AnalysisOutput, lang string) string {
+	if output.Changes == nil || output.Tree == nil {
+		return ""
+	}
+	c := output.Changes
+	var md strings.Builder
+	md.WriteString(fmt.Sprintf(tr(lang, "## Changes since `%s`")+"\n\n", c.Since))
+	commit := c.Commit
+	if len(commit) > 8 {
+		commit = commit[:8]
+	}
+	md.WriteString(fmt.Sprintf(tr(lang, "Compared with commit `%s`: %d added, %d modified, %d renamed, %d deleted.")+"\n\n",
+		commit, c.Added, c.Modified, c.Renamed, c.Deleted))
+
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if n.Type == "file" && n.ChangeStatus != "" && n.ChangeStatus != StatusUnchanged {
+			md.WriteString(fmt.Sprintf("- **%s** `%s`", tr(lang, n.ChangeStatus), displayPath(output.Tree, n)))
+			if n.Description != "" {
+				md.WriteString(" — " + firstSentence(n.Description))
+			}
+			md.WriteString("\n")
+		}
+		for _, ch := range n.Children {
+			walk(ch)
+		}
+	}
+	walk(output.Tree)
+	md.WriteString("\n")
+	return md.String()
+}
@@ -0,0 +1,194 @@
+package analyzer
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// maxChunkDependencyEdges caps the import edges listed in each chunk.
+const maxChunkDependencyEdges = 150
+
+// contextReserve is the part of each chunk's budget, in tokens, kept for the
+// path context header of the chunk root.
+const contextReserve = 500
+
+// chunkItem is a whole subtree, or the shallow entry of a folder too large to
+// fit in one chunk, whose children then become items of their own.
+type chunkItem struct {
+	node    *Node
+	parents []*Node // ancestors from the root to the node's parent
+	text    string
+	shallow bool // children are not included
+}
+
+// BuildArchitectureChunks splits the analyzed tree into architecture prompt
+// contents of at most budget estimated tokens. Chunks are made of whole subtrees taken in
+// tree order, serialized with SerializeCompact at the given detail level; a
+// subtree that does not fit on its own is split into its children. Each chunk
+// starts with a skeleton of the top-level folders and the path context of its
+// root, and ends with the import edges leaving it, as many as the room left
+// allows.
+func BuildArchitectureChunks(output *AnalysisOutput, budget int, detail string) []string {
+	root := output.Tree
+	skeleton := projectSkeleton(root)
+	itemBudget := budget - EstimateTokens(skeleton) - EstimateTokens(compactLegend) - contextReserve
+	if itemBudget < budget/4 {
+		itemBudget = budget / 4
+	}
+
+	var items []chunkItem
+	var visit func(n *Node, parents []*Node)
+	visit = func(n *Node, parents []*Node) {
+		text := SerializeCompact(root, n, detail, true)
+		if EstimateTokens(text) <= itemBudget || len(n.Children) == 0 {
+			items = append(items, chunkItem{node: n, parents: parents, text: TruncateTokens(text, itemBudget) + "\n"})
+			return
+		}
+		items = append(items, chunkItem{node: n, parents: parents, text: TruncateTokens(SerializeCompact(root, n, detail, false), itemBudget) + "\n", shallow: true})
+		childParents := append(append([]*Node(nil), parents...), n)
+		for _, child := range n.Children {
+			visit(child, childParents)
+		}
+	}
+	visit(root, nil)
+
+	var chunks []string
+	var current []chunkItem
+	size := 0
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		chunks = append(chunks, renderChunk(output, skeleton, current, budget))
+		current, size = nil, 0
+	}
+	for _, item := range items {
+		tokens := EstimateTokens(item.text)
+		if size+tokens > itemBudget {
+			flush()
+		}
+		current = append(current, item)
+		size += tokens
+	}
+	flush()
+	return chunks
+}
+
+// projectSkeleton lists the top-level folders with the first sentence of
+// their description, giving every chunk a global view of the project.
+func projectSkeleton(root *Node) string {
+	var sb strings.Builder
+	sb.WriteString(CompactStatistics(root))
+	sb.WriteString(fmt.Sprintf("Project skeleton (top-level entries of %s):\n", root.Name))
+	for _, child := range root.Children {
+		if child.Type != "directory" {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("- %s/", child.Name))
+		if sentence := firstSentence(child.Description); sentence != "" {
+			sb.WriteString(" — " + sentence)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func renderChunk(output *AnalysisOutput, skeleton string, items []chunkItem, budget int) string {
+	root := output.Tree
+	context := commonParents(items)
+
+	var sb strings.Builder
+	sb.WriteString(skeleton)
+	sb.WriteString("\nPath context of this chunk:\n")
+	var header strings.Builder
+	for depth, p := range context {
+		header.WriteString(fmt.Sprintf("%s📁 %s", strings.Repeat("  ", depth), p.Name))
+		if sentence := firstSentence(p.Description); sentence != "" {
+			header.WriteString(" — " + sentence)
+		}
+		header.WriteString("\n")
+	}
+	if len(context) == 0 {
+		header.WriteString("(project root)\n")
+	}
+	sb.WriteString(TruncateTokens(header.String(), contextReserve-50) + "\n")
+
+	sb.WriteString("\n" + compactLegend)
+	for _, item := range items {
+		sb.WriteString(item.text)
+	}
+
+	if g := chunkDependencyGraph(output.DependencyGraph, root, items); g != nil {
+		const title = "\nDependency Graph (package -> imported package, import count):\n"
+		room := budget - EstimateTokens(sb.String()) - EstimateTokens(title)
+		if edges := fitDependencyEdges(g, room); edges != "" {
+			sb.WriteString(title)
+			sb.WriteString(edges)
+		}
+	}
+	return sb.String()
+}
+
+// fitDependencyEdges formats as many edges of g as fit in tokens, up to
+// maxChunkDependencyEdges, the most used first.
+func fitDependencyEdges(g *DependencyGraph, tokens int) string {
+	fitted := ""
+	lo, hi := 1, min(len(g.Edges), maxChunkDependencyEdges)
+	for lo <= hi {
+		n := (lo + hi) / 2
+		if edges := FormatDependencyEdges(g, n); EstimateTokens(edges) <= tokens {
+			fitted, lo = edges, n+1
+		} else {
+			hi = n - 1
+		}
+	}
+	return fitted
+}
+
+// commonParents returns the ancestor chain shared by every item of a chunk.
+func commonParents(items []chunkItem) []*Node {
+	if len(items) == 0 {
+		return nil
+	}
+	common := append([]*Node(nil), items[0].parents...)
+	for _, item := range items[1:] {
+		n := 0
+		for n < len(common) && n < len(item.parents) && common[n] == item.parents[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	return common
+}
+
+// chunkDependencyGraph keeps the edges whose source package is covered by
+// the chunk: a package inside one of its whole subtrees, a folder listed
+// without its children, or the folder of one of its files.
+func chunkDependencyGraph(g *DependencyGraph, root *Node, items []chunkItem) *DependencyGraph {
+	if g == nil {
+		return nil
+	}
+	inChunk := func(id string) bool {
+		for _, item := range items {
+			rel := relPath(root, item.node)
+			if item.node.Type != "directory" {
+				if path.Dir(rel) == id {
+					return true
+				}
+				continue
+			}
+			if id == rel || (!item.shallow && (rel == "." || strings.HasPrefix(id, rel+"/"))) {
+				return true
+			}
+		}
+		return false
+	}
+	sub := &DependencyGraph{Nodes: g.Nodes}
+	for _, e := range g.Edges {
+		if inChunk(e.From) {
+			sub.Edges = append(sub.Edges, e)
+		}
+	}
+	return sub
+}
@@ -0,0 +1,50 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestArchitectureChunksFitBudget(t *testing.T) {
+	root := &Node{Path: "/p", Name: "p", Type: "directory", Description: "The project."}
+	graph := &DependencyGraph{}
+	for i := 0; i < 12; i++ {
+		name := fmt.Sprintf("pkg%02d", i)
+		dir := &Node{Path: "/p/" + name, Name: name, Type: "directory", Description: "A package. " + strings.Repeat("It does things. ", 20)}
+		for j := 0; j < 4; j++ {
+			dir.Children = append(dir.Children, &Node{
+				Path: fmt.Sprintf("/p/%s/f%d.go", name, j), Name: fmt.Sprintf("f%d.go", j), Type: "file",
+				Description: strings.Repeat("Handles a part of the package. ", 10),
+			})
+		}
+		root.Children = append(root.Children, dir)
+		graph.Nodes = append(graph.Nodes, GraphNode{ID: name})
+		for k := 0; k < 200; k++ {
+			to := fmt.Sprintf("example.com/some/external/module%03d", k)
+			graph.Edges = append(graph.Edges, GraphEdge{From: name, To: to, Count: 200 - k})
+		}
+	}
+	for k := 0; k < 200; k++ {
+		graph.Nodes = append(graph.Nodes, GraphNode{ID: fmt.Sprintf("example.com/some/external/module%03d", k), External: true})
+	}
+	output := &AnalysisOutput{Tree: root, DependencyGraph: graph}
+
+	const budget = 3000
+	chunks := BuildArchitectureChunks(output, budget, "")
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want the tree split", len(chunks))
+	}
+	withEdges := 0
+	for i, c := range chunks {
+		if tokens := EstimateTokens(c); tokens > budget {
+			t.Errorf("chunk %d has %d tokens, more than the budget of %d", i, tokens, budget)
+		}
+		if strings.Contains(c, "Dependency Graph") {
+			withEdges++
+		}
+	}
+	if withEdges == 0 {
+		t.Errorf("no chunk lists dependency edges")
+	}
+}
@@ -0,0 +1,151 @@
+package analyzer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Detail levels of the compact tree serialization sent to the architecture
+// step.
+const (
+	// DetailMinimal lists every path with the first sentence of folder
+	// descriptions only
+	DetailMinimal = "minimal"
+	// DetailStandard adds full descriptions of files and folders
+	DetailStandard = "standard"
+	// DetailFull adds the beginning of each file's content
+	DetailFull = "full"
+)
+
+const (
+	compactExcerptLines  = 8
+	compactExcerptSize   = 400
+	compactTopExtensions = 10
+)
+
+// compactLegend explains the compact serialization to the model.
+const compactLegend = "Project tree: one entry per line, indented by depth; folders end with \"/\", " +
+	"descriptions follow \" — \", lines starting with \"|\" are excerpts of the file above.\n"
+
+// SerializeCompact renders a subtree as indented path lines. The first line
+// holds the path of n relative to root; descendants follow with their names,
+// indented by depth. withChildren=false renders n alone.
+func SerializeCompact(root, n *Node, detail string, withChildren bool) string {
+	var sb strings.Builder
+	var write func(n *Node, label string, depth int)
+	write = func(n *Node, label string, depth int) {
+		indent := strings.Repeat("  ", depth)
+		sb.WriteString(indent + label)
+		if n.Type == "directory" && label != "./" {
+			sb.WriteString("/")
+		}
+		if desc := compactDescription(n, detail); desc != "" {
+			sb.WriteString(" — " + desc)
+		}
+		sb.WriteString("\n")
+		if detail == DetailFull && n.Type != "directory" {
+			for _, line := range compactExcerpt(n.Content) {
+				sb.WriteString(indent + "  | " + line + "\n")
+			}
+		}
+		if depth > 0 || withChildren {
+			for _, child := range n.Children {
+				write(child, child.Name, depth+1)
+			}
+		}
+	}
+
+	label := relPath(root, n)
+	if label == "." {
+		label = "./"
+	}
+	write(n, label, 0)
+	return sb.String()
+}
+
+func compactDescription(n *Node, detail string) string {
+	desc := strings.Join(strings.Fields(n.Description), " ")
+	if detail == DetailMinimal {
+		if n.Type != "directory" {
+			return ""
+		}
+		return firstSentence(desc)
+	}
+	return desc
+}
+
+// compactExcerpt returns the first non-empty lines of a file's content,
+// capped in lines and size.
+func compactExcerpt(content string) []string {
+	var lines []string
+	size := 0
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if len(lines) == compactExcerptLines || size+len(line) > compactExcerptSize {
+			lines = append(lines, "…")
+			break
+		}
+		lines = append(lines, line)
+		size += len(line)
+	}
+	return lines
+}
+
+// CompactStatistics summarizes the tree: file and folder counts and the most
+// common file extensions.
+func CompactStatistics(root *Node) string {
+	files, folders := 0, 0
+	extensions := make(map[string]int)
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if n.Type == "directory" {
+			if n != root {
+				folders++
+			}
+		} else {
+			files++
+			ext := strings.ToLower(filepath.Ext(n.Name))
+			if ext == "" {
+				ext = "(none)"
+			}
+			extensions[ext]++
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	type extCount struct {
+		ext   string
+		count int
+	}
+	var counts []extCount
+	for ext, count := range extensions {
+		counts = append(counts, extCount{ext, count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].count != counts[j].count {
+			return counts[i].count > counts[j].count
+		}
+		return counts[i].ext < counts[j].ext
+	})
+	if len(counts) > compactTopExtensions {
+		counts = counts[:compactTopExtensions]
+	}
+	parts := make([]string, len(counts))
+	for i, c := range counts {
+		parts[i] = fmt.Sprintf("%s %d", c.ext, c.count)
+	}
+
+	stats := fmt.Sprintf("Statistics: %d files, %d folders", files, folders)
+	if len(parts) > 0 {
+		stats += "; extensions: " + strings.Join(parts, ", ")
+	}
+	return stats + "\n"
+}
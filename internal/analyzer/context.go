@@ -0,0 +1,140 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"archi/internal/config"
+)
+
+// minContextSectionTokens is the smallest room worth giving to a folder
+// context; below it the remaining folder contexts are left out.
+const minContextSectionTokens = 32
+
+// ProjectContext provides the domain context prepended to the prompts: the
+// project context (the context config key and the context file) and the
+// per-folder context files found between the analyzed root and a path.
+type ProjectContext struct {
+	root       string
+	global     string
+	folderFile string
+	maxTokens  int
+
+	mu      sync.Mutex
+	folders map[string]string // directory -> folder context, "" when absent
+}
+
+// LoadProjectContext reads the project context for the tree analyzed at root.
+// A relative contextFile is looked up under root first, then in the working
+// directory; a missing file is not an error.
+func LoadProjectContext(cfg *config.Config, root string) (*ProjectContext, error) {
+	pc := &ProjectContext{
+		root:       filepath.Clean(root),
+		folderFile: cfg.FolderContextFile,
+		maxTokens:  cfg.Budget.MaxContextTokens,
+		folders:    make(map[string]string),
+	}
+
+	var parts []string
+	if text := strings.TrimSpace(cfg.Context); text != "" {
+		parts = append(parts, text)
+	}
+	if cfg.ContextFile != "" {
+		candidates := []string{cfg.ContextFile}
+		if !filepath.IsAbs(cfg.ContextFile) {
+			candidates = []string{filepath.Join(pc.root, cfg.ContextFile), cfg.ContextFile}
+		}
+		for _, file := range candidates {
+			data, err := os.ReadFile(file)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error reading context file %s: %w", file, err)
+			}
+			if text := strings.TrimSpace(string(data)); text != "" {
+				fmt.Printf("📘 Using project context from %s\n", file)
+				parts = append(parts, text)
+			}
+			break
+		}
+	}
+	pc.global = strings.Join(parts, "\n\n")
+	return pc, nil
+}
+
+// For returns the context of the files and folders inside dir: the project
+// context followed by the context files of dir and its parents, from the root
+// down. It stays within budget.maxContextTokens, favoring the folders closest
+// to dir.
+func (pc *ProjectContext) For(dir string) string {
+	if pc == nil {
+		return ""
+	}
+
+	// Folder contexts from dir up to the root, skipped for paths outside it
+	var sections []string
+	rel, err := filepath.Rel(pc.root, filepath.Clean(dir))
+	if pc.folderFile != "" && err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		for {
+			if text := pc.folderContext(filepath.Join(pc.root, rel)); text != "" {
+				sections = append(sections, fmt.Sprintf("Context of folder %s:\n%s", filepath.ToSlash(rel), text))
+			}
+			if rel == "." {
+				break
+			}
+			rel = filepath.Dir(rel)
+		}
+	}
+
+	if pc.maxTokens <= 0 {
+		return joinContext(pc.global, sections)
+	}
+
+	global := pc.global
+	remaining := pc.maxTokens
+	if len(sections) > 0 {
+		global = TruncateTokens(global, pc.maxTokens/2)
+	}
+	remaining -= EstimateTokens(global)
+	var kept []string
+	for _, section := range sections {
+		if remaining < minContextSectionTokens {
+			break
+		}
+		section = TruncateTokens(section, remaining)
+		remaining -= EstimateTokens(section)
+		kept = append(kept, section)
+	}
+	return TruncateTokens(joinContext(global, kept), pc.maxTokens)
+}
+
+// joinContext joins the project context with folder sections listed from the
+// deepest folder up, so that the output reads from the root down.
+func joinContext(global string, sections []string) string {
+	var parts []string
+	if global != "" {
+		parts = append(parts, global)
+	}
+	for i := len(sections) - 1; i >= 0; i-- {
+		parts = append(parts, sections[i])
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func (pc *ProjectContext) folderContext(dir string) string {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if text, ok := pc.folders[dir]; ok {
+		return text
+	}
+	var text string
+	if data, err := os.ReadFile(filepath.Join(dir, pc.folderFile)); err == nil {
+		text = strings.TrimSpace(string(data))
+	}
+	pc.folders[dir] = text
+	return text
+}
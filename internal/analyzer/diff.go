@@ -0,0 +1,172 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContextLines = 3
+
+// diffOp is one line of an edit script: ' ' kept, '-' removed, '+' added.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines computes a shortest edit script between a and b with Myers'
+// O(ND) algorithm.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	max := n + m
+	off := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[off+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, diffOp{'+', b[y-1]})
+				y--
+			} else {
+				ops = append(ops, diffOp{'-', a[x-1]})
+				x--
+			}
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// UnifiedDiff returns a unified diff between two texts, or "" when they are
+// identical.
+func UnifiedDiff(fromName, toName, from, to string) string {
+	ops := diffLines(splitLines(from), splitLines(to))
+
+	changed := false
+	for _, op := range ops {
+		if op.kind != ' ' {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", fromName, toName))
+
+	// Walk the edit script, emitting hunks with up to diffContextLines of
+	// unchanged lines around each change.
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		start := i - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContextLines {
+				end += diffContextLines
+				if end > len(ops) {
+					end = len(ops)
+				}
+				break
+			}
+			end = run
+		}
+
+		fromLine, toLine := 1, 1
+		for _, op := range ops[:start] {
+			if op.kind != '+' {
+				fromLine++
+			}
+			if op.kind != '-' {
+				toLine++
+			}
+		}
+		fromCount, toCount := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				fromCount++
+			}
+			if op.kind != '-' {
+				toCount++
+			}
+		}
+		if fromCount == 0 {
+			fromLine--
+		}
+		if toCount == 0 {
+			toLine--
+		}
+
+		out.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", fromLine, fromCount, toLine, toCount))
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			out.WriteByte('\n')
+		}
+		i = end
+	}
+	return out.String()
+}
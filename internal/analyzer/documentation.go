@@ -0,0 +1,175 @@
+package analyzer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// docAnchors assigns a unique, stable HTML anchor to every node, derived from
+// its path relative to the analyzed root.
+type docAnchors struct {
+	root   string
+	byNode map[*Node]string
+	used   map[string]int
+}
+
+func newDocAnchors(root *Node) *docAnchors {
+	d := &docAnchors{root: root.Path, byNode: make(map[*Node]string), used: make(map[string]int)}
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		d.assign(n)
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+	return d
+}
+
+func (d *docAnchors) assign(n *Node) {
+	rel, err := filepath.Rel(d.root, n.Path)
+	if err != nil || rel == "." {
+		rel = n.Name
+	}
+	var slug strings.Builder
+	lastDash := false
+	for _, r := range strings.ToLower(filepath.ToSlash(rel)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			slug.WriteRune(r)
+			lastDash = false
+		} else if !lastDash {
+			slug.WriteRune('-')
+			lastDash = true
+		}
+	}
+	prefix := "file-"
+	if n.Type == "directory" {
+		prefix = "dir-"
+	}
+	anchor := prefix + strings.Trim(slug.String(), "-")
+	if count := d.used[anchor]; count > 0 {
+		d.used[anchor]++
+		anchor = fmt.Sprintf("%s-%d", anchor, count)
+	} else {
+		d.used[anchor] = 1
+	}
+	d.byNode[n] = anchor
+}
+
+func (d *docAnchors) of(n *Node) string {
+	return d.byNode[n]
+}
+
+// generateDocumentation renders the description-rich documentation layout: a
+// table of contents, a linked tree, then one section per folder with its
+// description and a table of its files.
+func generateDocumentation(root *Node, lang string) string {
+	var md strings.Builder
+	anchors := newDocAnchors(root)
+
+	var folders []*Node
+	var collect func(n *Node)
+	collect = func(n *Node) {
+		if n.Type == "directory" {
+			folders = append(folders, n)
+		}
+		for _, child := range n.Children {
+			collect(child)
+		}
+	}
+	collect(root)
+
+	md.WriteString(fmt.Sprintf(tr(lang, "# %s Documentation")+"\n\n", root.Name))
+	if desc := strings.TrimSpace(root.Description); desc != "" {
+		md.WriteString(desc + "\n\n")
+	}
+
+	md.WriteString(tr(lang, "## Table of Contents") + "\n\n")
+	var toc func(n *Node, depth int)
+	toc = func(n *Node, depth int) {
+		if n.Type != "directory" {
+			return
+		}
+		md.WriteString(fmt.Sprintf("%s- [%s](#%s)\n", strings.Repeat("  ", depth), n.Name, anchors.of(n)))
+		for _, child := range n.Children {
+			toc(child, depth+1)
+		}
+	}
+	toc(root, 0)
+	md.WriteString("\n")
+
+	md.WriteString(tr(lang, "## Tree Structure") + "\n\n")
+	var tree func(n *Node, depth int)
+	tree = func(n *Node, depth int) {
+		md.WriteString(fmt.Sprintf("%s- %s [%s](#%s)\n", strings.Repeat("  ", depth), nodeIcon(n), n.Name, anchors.of(n)))
+		for _, child := range n.Children {
+			tree(child, depth+1)
+		}
+	}
+	tree(root, 0)
+	md.WriteString("\n")
+
+	md.WriteString(tr(lang, "## Folders") + "\n\n")
+	for _, folder := range folders {
+		md.WriteString(fmt.Sprintf("### <a id=\"%s\"></a>📁 %s\n\n", anchors.of(folder), displayPath(root, folder)))
+		if desc := strings.TrimSpace(folder.Description); desc != "" {
+			md.WriteString(desc + "\n\n")
+		} else {
+			md.WriteString(tr(lang, "*No description available.*") + "\n\n")
+		}
+
+		var files, subfolders []*Node
+		for _, child := range folder.Children {
+			if child.Type == "directory" {
+				subfolders = append(subfolders, child)
+			} else {
+				files = append(files, child)
+			}
+		}
+
+		if len(subfolders) > 0 {
+			md.WriteString(tr(lang, "**Subfolders:** "))
+			for i, sub := range subfolders {
+				if i > 0 {
+					md.WriteString(", ")
+				}
+				md.WriteString(fmt.Sprintf("[%s](#%s)", sub.Name, anchors.of(sub)))
+			}
+			md.WriteString("\n\n")
+		}
+
+		if len(files) > 0 {
+			md.WriteString(tr(lang, "| File | Description |") + "\n")
+			md.WriteString("|------|-------------|\n")
+			for _, f := range files {
+				md.WriteString(fmt.Sprintf("| <a id=\"%s\"></a>%s %s | %s |\n",
+					anchors.of(f), nodeIcon(f), escapeTableCell(f.Name), escapeTableCell(f.Description)))
+			}
+			md.WriteString("\n")
+		}
+	}
+
+	return md.String()
+}
+
+// displayPath returns the node path relative to the analyzed root, using the
+// root's name for the root itself.
+func displayPath(root, n *Node) string {
+	if n == root {
+		return root.Name
+	}
+	rel, err := filepath.Rel(root.Path, n.Path)
+	if err != nil {
+		return n.Path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// escapeTableCell flattens text to a single line usable inside a markdown
+// table cell.
+func escapeTableCell(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.ReplaceAll(s, "|", "\\|")
+}
@@ -0,0 +1,174 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DuplicateGroup is a set of files with identical content.
+type DuplicateGroup struct {
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+	// Paths of the copies relative to the analyzed root; the first one is
+	// analyzed and the others share its description
+	Paths []string `json:"paths"`
+	// WastedBytes is the size taken by all copies but one
+	WastedBytes int64 `json:"wastedBytes"`
+}
+
+// markDuplicates sets DuplicateOf on the files whose content is identical to
+// a file earlier in walk order, and returns the other files: one per content.
+// Identical content is detected with the SHA-256 of the metadata, so files
+// without metadata are all kept.
+func (a *Analyzer) markDuplicates(fileNodes []*Node) []*Node {
+	if !a.config.Duplicates.Enabled {
+		return fileNodes
+	}
+	first := make(map[string]*Node)
+	unique := make([]*Node, 0, len(fileNodes))
+	var copies int
+	var wasted int64
+	for _, n := range fileNodes {
+		m := n.Metadata
+		if m == nil || m.SHA256 == "" || m.Size < a.config.Duplicates.MinSize {
+			unique = append(unique, n)
+			continue
+		}
+		if f, ok := first[m.SHA256]; ok {
+			n.DuplicateOf = f.Path
+			copies++
+			wasted += m.Size
+			continue
+		}
+		first[m.SHA256] = n
+		unique = append(unique, n)
+	}
+	if copies > 0 {
+		fmt.Printf("\n🧬 %d files are copies of other files (%s), their descriptions will be shared\n", copies, formatBytes(wasted))
+	}
+	return unique
+}
+
+// filesToAnalyze replaces the copies among the changed files of a --since
+// analysis by the file they copy, unless that file already has a description
+// it can share.
+func filesToAnalyze(changed, fileNodes []*Node) []*Node {
+	byPath := make(map[string]*Node, len(fileNodes))
+	for _, n := range fileNodes {
+		byPath[n.Path] = n
+	}
+	isChanged := make(map[*Node]bool, len(changed))
+	for _, n := range changed {
+		isChanged[n] = true
+	}
+	seen := make(map[*Node]bool)
+	var files []*Node
+	for _, n := range changed {
+		if n.DuplicateOf != "" {
+			original := byPath[n.DuplicateOf]
+			if original == nil || (original.Description != "" && !isChanged[original]) {
+				continue
+			}
+			n = original
+		}
+		if !seen[n] {
+			seen[n] = true
+			files = append(files, n)
+		}
+	}
+	return files
+}
+
+// shareDuplicateDescriptions gives the copies the description, content,
+// security findings, redacted secrets and personal data counts of the file
+// they copy.
+func shareDuplicateDescriptions(fileNodes []*Node) {
+	byPath := make(map[string]*Node, len(fileNodes))
+	for _, n := range fileNodes {
+		byPath[n.Path] = n
+	}
+	for _, n := range fileNodes {
+		if original := byPath[n.DuplicateOf]; original != nil {
+			n.Description = original.Description
+			n.Content = original.Content
+			n.Security = original.Security
+			n.Secrets = original.Secrets
+			n.PII = original.PII
+			n.Sampled = original.Sampled
+		}
+	}
+}
+
+// FindDuplicates groups the copies marked in the tree with the file they
+// copy, the groups wasting the most space first.
+func FindDuplicates(root *Node) []DuplicateGroup {
+	if root == nil {
+		return nil
+	}
+	byPath := make(map[string]*Node)
+	copies := make(map[string][]*Node)
+	var originals []string
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if n.Type == "file" && n.ChangeStatus != StatusDeleted {
+			byPath[n.Path] = n
+			if n.DuplicateOf != "" {
+				if _, ok := copies[n.DuplicateOf]; !ok {
+					originals = append(originals, n.DuplicateOf)
+				}
+				copies[n.DuplicateOf] = append(copies[n.DuplicateOf], n)
+			}
+		}
+		for _, ch := range n.Children {
+			walk(ch)
+		}
+	}
+	walk(root)
+
+	var groups []DuplicateGroup
+	for _, p := range originals {
+		original := byPath[p]
+		if original == nil || original.Metadata == nil {
+			continue
+		}
+		g := DuplicateGroup{SHA256: original.Metadata.SHA256, Size: original.Metadata.Size, Paths: []string{displayPath(root, original)}}
+		for _, c := range copies[p] {
+			g.Paths = append(g.Paths, displayPath(root, c))
+		}
+		g.WastedBytes = g.Size * int64(len(g.Paths)-1)
+		groups = append(groups, g)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].WastedBytes != groups[j].WastedBytes {
+			return groups[i].WastedBytes > groups[j].WastedBytes
+		}
+		return groups[i].Paths[0] < groups[j].Paths[0]
+	})
+	return groups
+}
+
+// generateDuplicatesSection lists the groups of identical files.
+func generateDuplicatesSection(groups []DuplicateGroup, lang string) string {
+	if len(groups) == 0 {
+		return ""
+	}
+	var wasted int64
+	for _, g := range groups {
+		wasted += g.WastedBytes
+	}
+	var md strings.Builder
+	md.WriteString(tr(lang, "## Duplicates") + "\n\n")
+	md.WriteString(fmt.Sprintf(tr(lang, "%d groups of identical files, %s wasted by the copies.")+"\n\n", len(groups), formatBytes(wasted)))
+	md.WriteString(tr(lang, "| Files | Copies | Size | Wasted |") + "\n")
+	md.WriteString("|---|---:|---:|---:|\n")
+	for _, g := range groups {
+		paths := make([]string, len(g.Paths))
+		for i, p := range g.Paths {
+			paths[i] = "`" + escapeTableCell(p) + "`"
+		}
+		md.WriteString(fmt.Sprintf("| %s | %d | %s | %s |\n", strings.Join(paths, "<br>"), len(g.Paths), formatBytes(g.Size), formatBytes(g.WastedBytes)))
+	}
+	md.WriteString("\n")
+	return md.String()
+}
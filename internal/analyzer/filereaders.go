@@ -1,7 +1,15 @@
 package analyzer
 
 import (
+	"encoding/base64"
 	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"regexp"
 	"strings"
 
 	"baliance.com/gooxml/document"
@@ -76,3 +84,108 @@ func ReadPdf(path string) (content string, err error) {
 	}
 	return textBuilder.String(), nil
 }
+
+// emailHeaders are the headers kept from an email, in this order.
+var emailHeaders = []string{"From", "To", "Cc", "Date", "Subject"}
+
+var htmlTagRe = regexp.MustCompile(`(?s)<(script|style)[^>]*>.*?</(script|style)>|<[^>]+>`)
+
+// ReadEml returns the headers of an email, its text body (the HTML body with
+// its tags removed when there is no plain text one) and the names of its
+// attachments.
+func ReadEml(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	msg, err := mail.ReadMessage(f)
+	if err != nil {
+		return "", err
+	}
+
+	var textBuilder strings.Builder
+	decoder := new(mime.WordDecoder)
+	for _, name := range emailHeaders {
+		value := msg.Header.Get(name)
+		if value == "" {
+			continue
+		}
+		if decoded, err := decoder.DecodeHeader(value); err == nil {
+			value = decoded
+		}
+		textBuilder.WriteString(name + ": " + value + "\n")
+	}
+	textBuilder.WriteString("\n")
+
+	var plain, html string
+	var attachments []string
+	var walk func(header map[string][]string, body io.Reader) error
+	walk = func(header map[string][]string, body io.Reader) error {
+		h := mail.Header(header)
+		mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+		if err != nil {
+			mediaType = "text/plain"
+		}
+		if strings.HasPrefix(mediaType, "multipart/") {
+			mr := multipart.NewReader(body, params["boundary"])
+			for {
+				part, err := mr.NextRawPart()
+				if err == io.EOF {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				if err := walk(part.Header, part); err != nil {
+					return err
+				}
+			}
+		}
+
+		disposition, dparams, _ := mime.ParseMediaType(h.Get("Content-Disposition"))
+		if disposition == "attachment" || (!strings.HasPrefix(mediaType, "text/") && mediaType != "") {
+			name := dparams["filename"]
+			if name == "" {
+				name = params["name"]
+			}
+			if name == "" {
+				name = mediaType
+			}
+			attachments = append(attachments, name)
+			return nil
+		}
+
+		switch strings.ToLower(h.Get("Content-Transfer-Encoding")) {
+		case "quoted-printable":
+			body = quotedprintable.NewReader(body)
+		case "base64":
+			body = base64.NewDecoder(base64.StdEncoding, body)
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		if mediaType == "text/html" {
+			if html == "" {
+				html = string(data)
+			}
+		} else if plain == "" {
+			plain = string(data)
+		}
+		return nil
+	}
+	if err := walk(msg.Header, msg.Body); err != nil {
+		return "", err
+	}
+
+	if plain == "" && html != "" {
+		plain = strings.Join(strings.Fields(htmlTagRe.ReplaceAllString(html, " ")), " ")
+	}
+	textBuilder.WriteString(strings.TrimSpace(plain) + "\n")
+	if len(attachments) > 0 {
+		textBuilder.WriteString("\nAttachments: " + strings.Join(attachments, ", ") + "\n")
+	}
+	return textBuilder.String(), nil
+}
@@ -0,0 +1,343 @@
+package analyzer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"archi/internal/gitrepo"
+)
+
+// minHotspotCommits leaves out files changed once, which are not churning
+// however large they are.
+const minHotspotCommits = 2
+
+// History summarizes the git history of a file or folder over the commits
+// read (history.maxCommits). For folders, commits and authors are counted
+// once across all their descendants.
+type History struct {
+	Commits      int        `json:"commits"`
+	LinesAdded   int        `json:"linesAdded"`
+	LinesDeleted int        `json:"linesDeleted"`
+	Authors      int        `json:"authors"`
+	FirstCommit  *time.Time `json:"firstCommit,omitempty"`
+	LastCommit   *time.Time `json:"lastCommit,omitempty"`
+}
+
+// Churn is the number of lines added and deleted.
+func (h *History) Churn() int {
+	return h.LinesAdded + h.LinesDeleted
+}
+
+// pathHistory accumulates the history of a path while walking the commits.
+type pathHistory struct {
+	commits        map[int]bool
+	authors        map[string]bool
+	added, deleted int
+	first, last    time.Time
+}
+
+func newPathHistory() *pathHistory {
+	return &pathHistory{commits: make(map[int]bool), authors: make(map[string]bool)}
+}
+
+func (p *pathHistory) touch(when time.Time) {
+	if p.last.IsZero() || when.After(p.last) {
+		p.last = when
+	}
+	if p.first.IsZero() || when.Before(p.first) {
+		p.first = when
+	}
+}
+
+func (p *pathHistory) merge(o *pathHistory) {
+	for c := range o.commits {
+		p.commits[c] = true
+	}
+	for a := range o.authors {
+		p.authors[a] = true
+	}
+	p.added += o.added
+	p.deleted += o.deleted
+	if !o.last.IsZero() {
+		p.touch(o.last)
+		p.touch(o.first)
+	}
+}
+
+func (p *pathHistory) history() *History {
+	h := &History{Commits: len(p.commits), LinesAdded: p.added, LinesDeleted: p.deleted, Authors: len(p.authors)}
+	if !p.last.IsZero() {
+		first, last := p.first, p.last
+		h.FirstCommit, h.LastCommit = &first, &last
+	}
+	return h
+}
+
+// collectHistory reads the git history of the repository containing the
+// analyzed directory in a single walk. It records the last commit of each file
+// tracked at HEAD in its metadata (metadata.git, within
+// metadata.gitMaxCommits commits) and the history of every node
+// (history.enabled, within history.maxCommits commits). Files keep their
+// history across renames of identical content.
+func (a *Analyzer) collectHistory(rootPath string, root *Node, fileNodes []*Node) {
+	withHistory := a.config.History.Enabled
+	withLastCommits := a.config.Metadata.Enabled && a.config.Metadata.Git
+	if root == nil || !withHistory && !withLastCommits {
+		return
+	}
+	repo, err := gitrepo.Open(rootPath)
+	if err != nil {
+		if err != gitrepo.ErrNotRepository {
+			fmt.Printf("⚠️  Could not read git history: %v\n", err)
+		}
+		return
+	}
+	head, err := repo.Resolve("HEAD")
+	if err != nil {
+		// A repository without commits has no history to report
+		return
+	}
+	prefix, ok := repo.RelPath(rootPath)
+	if !ok {
+		return
+	}
+
+	// Files awaiting their last commit, by path in the repository
+	var pending map[string][]*Node
+	if withLastCommits {
+		if pending, err = trackedFiles(repo, head, fileNodes); err != nil {
+			fmt.Printf("⚠️  Could not read git history: %v\n", err)
+			return
+		}
+	}
+
+	maxCommits := a.config.History.MaxCommits
+	if withHistory {
+		if maxCommits > 0 {
+			fmt.Printf("\n📜 Reading git history (up to %d commits)...\n", maxCommits)
+		} else {
+			fmt.Printf("\n📜 Reading git history...\n")
+		}
+	}
+	maxLastCommits := a.config.Metadata.GitMaxCommits
+	stats := make(map[string]*pathHistory)
+	// Walking backwards, a rename maps the old path to the file's current path
+	alias := make(map[string]string)
+	visited := 0
+	more := func() (history, lastCommits bool) {
+		history = withHistory && (maxCommits <= 0 || visited < maxCommits)
+		lastCommits = len(pending) > 0 && (maxLastCommits <= 0 || visited < maxLastCommits)
+		return history, lastCommits
+	}
+	err = repo.Walk(head, func(c *gitrepo.Commit, changes []gitrepo.Change) bool {
+		history, lastCommits := more()
+		author := strings.ToLower(c.Author.Email)
+		if author == "" {
+			author = c.Author.Name
+		}
+		for _, ch := range changes {
+			if nodes, ok := pending[ch.Path]; ok && lastCommits {
+				when := c.Author.When
+				for _, n := range nodes {
+					n.Metadata.LastCommitAuthor = c.Author.Name
+					n.Metadata.LastCommitDate = &when
+				}
+				delete(pending, ch.Path)
+			}
+			if !history {
+				continue
+			}
+			path := ch.Path
+			if current, ok := alias[path]; ok {
+				path = current
+			}
+			if ch.Action == gitrepo.Renamed {
+				alias[ch.OldPath] = path
+			}
+			if prefix != "" && !strings.HasPrefix(path, prefix+"/") {
+				continue
+			}
+			added, deleted, err := repo.LineStats(ch)
+			if err != nil {
+				added, deleted = 0, 0
+			}
+			s := stats[path]
+			if s == nil {
+				s = newPathHistory()
+				stats[path] = s
+			}
+			s.commits[visited] = true
+			s.authors[author] = true
+			s.added += added
+			s.deleted += deleted
+			s.touch(c.Author.When)
+		}
+		visited++
+		history, lastCommits = more()
+		return history || lastCommits
+	})
+	if err != nil {
+		fmt.Printf("⚠️  Could not read git history: %v\n", err)
+		return
+	}
+	if !withHistory {
+		return
+	}
+	read := visited
+	if maxCommits > 0 {
+		read = min(read, maxCommits)
+	}
+	fmt.Printf("   %d commits read, %d paths changed\n", read, len(stats))
+
+	byPath := make(map[string]*pathHistory, len(stats))
+	for p, s := range stats {
+		byPath[repoNodePath(rootPath, prefix, p)] = s
+	}
+	aggregateHistory(root, byPath)
+}
+
+// trackedFiles indexes the file nodes with metadata by their path in the
+// repository, keeping only the files of the HEAD tree: untracked and ignored
+// files have no commit to find.
+func trackedFiles(repo *gitrepo.Repo, head gitrepo.Hash, fileNodes []*Node) (map[string][]*Node, error) {
+	commit, err := repo.Commit(head)
+	if err != nil {
+		return nil, err
+	}
+	files, err := repo.Files(commit.Tree)
+	if err != nil {
+		return nil, err
+	}
+	tracked := make(map[string][]*Node)
+	for _, n := range fileNodes {
+		rel, ok := repo.RelPath(n.Path)
+		if !ok || n.Metadata == nil {
+			continue
+		}
+		if _, ok := files[rel]; ok {
+			tracked[rel] = append(tracked[rel], n)
+		}
+	}
+	return tracked, nil
+}
+
+// aggregateHistory sets the history of n and its descendants, and returns the
+// accumulated history of n (nil when nothing below it changed).
+func aggregateHistory(n *Node, byPath map[string]*pathHistory) *pathHistory {
+	if n.Type != "directory" {
+		s := byPath[n.Path]
+		if s != nil {
+			n.History = s.history()
+		}
+		return s
+	}
+	var total *pathHistory
+	for _, ch := range n.Children {
+		if s := aggregateHistory(ch, byPath); s != nil {
+			if total == nil {
+				total = newPathHistory()
+			}
+			total.merge(s)
+		}
+	}
+	if total != nil {
+		n.History = total.history()
+	}
+	return total
+}
+
+// repoNodePath turns a slash-separated path relative to the working tree into
+// the path of its node in a tree analyzed at rootPath, prefix being rootPath
+// relative to the working tree.
+func repoNodePath(rootPath, prefix, p string) string {
+	if prefix != "" {
+		p = strings.TrimPrefix(p, prefix+"/")
+	}
+	return filepath.Join(rootPath, filepath.FromSlash(p))
+}
+
+// hotspotSize is the size used to rank hotspots: the line count, or an
+// estimate from the byte size for files without one.
+func hotspotSize(n *Node) int {
+	if n.Metadata != nil {
+		if n.Metadata.Lines > 0 {
+			return n.Metadata.Lines
+		}
+		if n.Metadata.Size > 0 {
+			return int(n.Metadata.Size/40) + 1
+		}
+	}
+	return 1
+}
+
+// FindHotspots returns up to limit files ranked by change frequency times
+// size: large files that keep changing, where changes are the most costly.
+func FindHotspots(root *Node, limit int) []*Node {
+	if root == nil || limit <= 0 {
+		return nil
+	}
+	var files []*Node
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if n.Type == "file" && n.History != nil && n.History.Commits >= minHotspotCommits {
+			files = append(files, n)
+		}
+		for _, ch := range n.Children {
+			walk(ch)
+		}
+	}
+	walk(root)
+
+	score := func(n *Node) int { return n.History.Commits * hotspotSize(n) }
+	sort.SliceStable(files, func(i, j int) bool {
+		if si, sj := score(files[i]), score(files[j]); si != sj {
+			return si > sj
+		}
+		return files[i].History.Churn() > files[j].History.Churn()
+	})
+	if len(files) > limit {
+		files = files[:limit]
+	}
+	return files
+}
+
+// FormatHotspots lists the hotspots for the architecture prompt, one per line.
+func FormatHotspots(root *Node, limit int) string {
+	var sb strings.Builder
+	for _, n := range FindHotspots(root, limit) {
+		h := n.History
+		sb.WriteString(fmt.Sprintf("%s: %d commits, +%d/-%d lines, %d authors, %d lines", displayPath(root, n), h.Commits, h.LinesAdded, h.LinesDeleted, h.Authors, hotspotSize(n)))
+		if h.LastCommit != nil {
+			sb.WriteString(", last changed " + h.LastCommit.Format("2006-01-02"))
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// generateHotspotsSection renders the hotspots as a markdown table.
+func generateHotspotsSection(root *Node, limit int, lang string) string {
+	hotspots := FindHotspots(root, limit)
+	if len(hotspots) == 0 {
+		return ""
+	}
+	var md strings.Builder
+	md.WriteString(tr(lang, "## Hotspots") + "\n\n")
+	md.WriteString(tr(lang, "Files that change often and are large, from the git history.") + "\n\n")
+	md.WriteString(tr(lang, "| File | Commits | Lines changed | Authors | Lines | Last change |") + "\n")
+	md.WriteString("|---|---:|---:|---:|---:|---|\n")
+	for _, n := range hotspots {
+		h := n.History
+		last := ""
+		if h.LastCommit != nil {
+			last = h.LastCommit.Format("2006-01-02")
+		}
+		md.WriteString(fmt.Sprintf("| `%s` | %d | +%d / -%d | %d | %d | %s |\n",
+			escapeTableCell(displayPath(root, n)), h.Commits, h.LinesAdded, h.LinesDeleted, h.Authors, hotspotSize(n), last))
+	}
+	md.WriteString("\n")
+	return md.String()
+}
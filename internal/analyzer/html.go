@@ -0,0 +1,77 @@
+package analyzer
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+)
+
+//go:embed templates/report.html
+var htmlReportTemplate string
+
+// htmlNode is the client-side view of a Node: content is dropped to keep the
+// page small and descriptions are pre-rendered to HTML.
+type htmlNode struct {
+	Name        string      `json:"name"`
+	Path        string      `json:"path"`
+	Type        string      `json:"type"`
+	Icon        string      `json:"icon"`
+	Description string      `json:"description,omitempty"`
+	DescHTML    string      `json:"descriptionHtml,omitempty"`
+	Children    []*htmlNode `json:"children,omitempty"`
+}
+
+func toHTMLNode(n *Node) *htmlNode {
+	h := &htmlNode{
+		Name:        n.Name,
+		Path:        n.Path,
+		Type:        n.Type,
+		Icon:        nodeIcon(n),
+		Description: n.Description,
+	}
+	if strings.TrimSpace(n.Description) != "" {
+		h.DescHTML = markdownToHTML(n.Description)
+	}
+	for _, child := range n.Children {
+		h.Children = append(h.Children, toHTMLNode(child))
+	}
+	return h
+}
+
+// GenerateHTMLReport renders a single offline HTML page with a collapsible,
+// searchable tree. When report is non-empty the architecture report is shown
+// in a side panel.
+func GenerateHTMLReport(rootNode *Node, report string) (string, error) {
+	tmpl, err := template.New("report").Parse(htmlReportTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error parsing HTML template: %w", err)
+	}
+
+	treeJSON, err := json.Marshal(toHTMLNode(rootNode))
+	if err != nil {
+		return "", fmt.Errorf("error marshalling tree: %w", err)
+	}
+
+	data := struct {
+		Title       string
+		GeneratedAt string
+		TreeJSON    template.JS
+		HasReport   bool
+		ReportHTML  template.HTML
+	}{
+		Title:       rootNode.Name,
+		GeneratedAt: time.Now().Format("2006-01-02 15:04:05"),
+		TreeJSON:    template.JS(treeJSON),
+		HasReport:   strings.TrimSpace(report) != "",
+		ReportHTML:  template.HTML(markdownToHTML(report)),
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("error rendering HTML report: %w", err)
+	}
+	return out.String(), nil
+}
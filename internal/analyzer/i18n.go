@@ -0,0 +1,186 @@
+package analyzer
+
+import "strings"
+
+// languageNames maps the supported language codes, and their common
+// spellings, to the code used by the translations and the name given to the
+// models.
+var languageNames = map[string]struct{ code, name string }{
+	"en":       {"en", "English"},
+	"english":  {"en", "English"},
+	"fr":       {"fr", "French"},
+	"french":   {"fr", "French"},
+	"français": {"fr", "French"},
+	"francais": {"fr", "French"},
+}
+
+// LanguageCode returns the code of a configured output language, or the
+// lower-cased value itself for languages without translations.
+func LanguageCode(language string) string {
+	key := strings.ToLower(strings.TrimSpace(language))
+	if l, ok := languageNames[key]; ok {
+		return l.code
+	}
+	if key == "" {
+		return "en"
+	}
+	return key
+}
+
+// LanguageName returns the name of a configured output language as written
+// in the prompts ("fr" gives "French"); unknown values are passed through.
+func LanguageName(language string) string {
+	key := strings.ToLower(strings.TrimSpace(language))
+	if l, ok := languageNames[key]; ok {
+		return l.name
+	}
+	if key == "" {
+		return "English"
+	}
+	return strings.TrimSpace(language)
+}
+
+// translations holds the generated markdown strings by language code, keyed
+// by their English text. Missing entries fall back to English.
+var translations = map[string]map[string]string{
+	"fr": {
+		// Analysis output
+		"# Directory Tree Analysis": "# Analyse de l'arborescence",
+		"This document shows the analyzed directory structure with AI-generated descriptions.": "Ce document présente l'arborescence analysée avec des descriptions générées par IA.",
+		"## Tree Structure": "## Arborescence",
+		"## Tree Diagram":   "## Diagramme de l'arborescence",
+		"## Legend":         "## Légende",
+		"Directory":         "Dossier",
+		"Text/Generic file": "Fichier texte/générique",
+		"Image file":        "Image",
+		"PDF document":      "Document PDF",
+		"Word document":     "Document Word",
+		"Excel spreadsheet": "Classeur Excel",
+		"Go source file":    "Fichier source Go",
+		"Markdown file":     "Fichier Markdown",
+		"JSON file":         "Fichier JSON",
+		"*Descriptions are AI-generated based on file content analysis.*": "*Les descriptions sont générées par IA à partir de l'analyse du contenu des fichiers.*",
+		"# %s Documentation":          "# Documentation de %s",
+		"## Table of Contents":        "## Table des matières",
+		"## Folders":                  "## Dossiers",
+		"*No description available.*": "*Aucune description disponible.*",
+		"**Subfolders:** ":            "**Sous-dossiers :** ",
+		"| File | Description |":      "| Fichier | Description |",
+		"## Changes since `%s`":       "## Modifications depuis `%s`",
+		"Compared with commit `%s`: %d added, %d modified, %d renamed, %d deleted.": "Comparaison avec le commit `%s` : %d ajoutés, %d modifiés, %d renommés, %d supprimés.",
+		"# Analysis Comparison":                 "# Comparaison des analyses",
+		"Comparing `%s` (old) with `%s` (new).": "Comparaison de `%s` (ancienne) avec `%s` (nouvelle).",
+		"- **Added:** %d":                       "- **Ajoutés :** %d",
+		"- **Removed:** %d":                     "- **Supprimés :** %d",
+		"- **Moved:** %d":                       "- **Déplacés :** %d",
+		"- **Modified:** %d":                    "- **Modifiés :** %d",
+		"- **Changed descriptions:** %d":        "- **Descriptions modifiées :** %d",
+		"- **Folders with size changes:** %d":   "- **Dossiers dont la taille a changé :** %d",
+		"## Evolution":                          "## Évolution",
+		"## Added":                              "## Ajouts",
+		"## Removed":                            "## Suppressions",
+		"## Moved":                              "## Déplacements",
+		"## Modified":                           "## Modifications",
+		"| File | Old size | New size |":        "| Fichier | Ancienne taille | Nouvelle taille |",
+		"## Changed Descriptions":               "## Descriptions modifiées",
+		"- **Before:** %s":                      "- **Avant :** %s",
+		"- **After:** %s":                       "- **Après :** %s",
+		"## Folder Size Changes":                "## Évolution de la taille des dossiers",
+		"| Folder | Old size | New size | Change | Files |": "| Dossier | Ancienne taille | Nouvelle taille | Variation | Fichiers |",
+		"## Duplicates": "## Doublons",
+		"%d groups of identical files, %s wasted by the copies.": "%d groupes de fichiers identiques, %s occupés par les copies.",
+		"| Files | Copies | Size | Wasted |":                     "| Fichiers | Copies | Taille | Espace perdu |",
+		"## Near Duplicates":                                     "## Quasi-doublons",
+		"Groups of files whose content is mostly identical, with the estimated similarity of their text.": "Groupes de fichiers au contenu presque identique, avec la similarité estimée de leur texte.",
+		"| Files | Similarity |": "| Fichiers | Similarité |",
+		"This document shows the analyzed directory structure with statistics computed locally, without AI descriptions.": "Ce document présente l'arborescence analysée avec des statistiques calculées localement, sans descriptions générées par IA.",
+		"## Inventory": "## Inventaire",
+		"%d files in %d folders, %s, %d lines of text.": "%d fichiers dans %d dossiers, %s, %d lignes de texte.",
+		"### File Types": "### Types de fichiers",
+		"| Extension | Language | Files | Size | Lines |": "| Extension | Langage | Fichiers | Taille | Lignes |",
+		"### Largest Files":         "### Fichiers les plus volumineux",
+		"| File | Size | Lines |":   "| Fichier | Taille | Lignes |",
+		"### Largest Folders":       "### Dossiers les plus volumineux",
+		"| Folder | Size | Files |": "| Dossier | Taille | Fichiers |",
+		"### Empty Folders":         "### Dossiers vides",
+		"## Security":               "## Sécurité",
+		"### Secrets":               "### Secrets",
+		"%d possible secrets found in %d files. They were redacted before the analysis and are not written to the output.": "%d secrets potentiels trouvés dans %d fichiers. Ils ont été masqués avant l'analyse et ne figurent pas dans les résultats.",
+		"| File | Line | Rule |": "| Fichier | Ligne | Règle |",
+		"### Personal Data":      "### Données personnelles",
+		"Personal data was replaced by placeholders in %d files before the analysis.": "Les données personnelles ont été remplacées par des marqueurs dans %d fichiers avant l'analyse.",
+		"| File | Categories |": "| Fichier | Catégories |",
+		"### Vulnerabilities":   "### Vulnérabilités",
+		"%d possible vulnerabilities found by the security review: %s.": "%d vulnérabilités potentielles trouvées par la revue de sécurité : %s.",
+		"# Security Report":              "# Rapport de sécurité",
+		"## Vulnerabilities":             "## Vulnérabilités",
+		"No vulnerabilities were found.": "Aucune vulnérabilité n'a été trouvée.",
+		"Findings marked *heuristic* come from local pattern matching and may be false positives; the others come from the AI review.": "Les constats marqués *heuristique* proviennent d'une recherche locale de motifs et peuvent être des faux positifs ; les autres proviennent de la revue par l'IA.",
+		"| Severity | File | Line | Category | Finding |": "| Gravité | Fichier | Ligne | Catégorie | Constat |",
+		"heuristic":            "heuristique",
+		"critical":             "critique",
+		"high":                 "élevée",
+		"medium":               "moyenne",
+		"low":                  "faible",
+		"info":                 "info",
+		"## Redacted Data":     "## Données masquées",
+		"## Licenses":          "## Licences",
+		"Project license: %s.": "Licence du projet : %s.",
+		"| License | Kind | License files | Files with an SPDX header |": "| Licence | Type | Fichiers de licence | Fichiers avec un en-tête SPDX |",
+		"permissive":                    "permissive",
+		"weak copyleft":                 "copyleft faible",
+		"strong copyleft":               "copyleft fort",
+		"### Incompatible Combinations": "### Combinaisons incompatibles",
+		"## Hotspots":                   "## Points chauds",
+		"Files that change often and are large, from the git history.":       "Fichiers volumineux qui changent souvent, d'après l'historique git.",
+		"| File | Commits | Lines changed | Authors | Lines | Last change |": "| Fichier | Commits | Lignes modifiées | Auteurs | Lignes | Dernière modification |",
+		"added":    "ajouté",
+		"modified": "modifié",
+		"renamed":  "renommé",
+		"deleted":  "supprimé",
+
+		// Estimation
+		"# File and Folder Estimation":                          "# Estimation des fichiers et dossiers",
+		"**Generated on:** %s":                                  "**Généré le :** %s",
+		"## Summary":                                            "## Résumé",
+		"- **Total Files:** %d":                                 "- **Nombre de fichiers :** %d",
+		"- **Total Folders:** %d":                               "- **Nombre de dossiers :** %d",
+		"- **Estimated Total Execution Time:** %s":              "- **Durée totale d'exécution estimée :** %s",
+		"  - File processing time (~4s each): %s":               "  - Traitement des fichiers (~4 s chacun) : %s",
+		"  - Folder processing time (~7s each): %s":             "  - Traitement des dossiers (~7 s chacun) : %s",
+		"## File Types Analysis":                                "## Analyse des types de fichiers",
+		"| Extension | Count | Estimated Time |":                "| Extension | Nombre | Durée estimée |",
+		"## Root Folders Analysis":                              "## Analyse des dossiers racine",
+		"| Folder Name | Files | Subfolders | Estimated Time |": "| Dossier | Fichiers | Sous-dossiers | Durée estimée |",
+		"## Detailed Root Folder Breakdown":                     "## Détail par dossier racine",
+		"- **Path:** `%s`":                                      "- **Chemin :** `%s`",
+		"- **Files:** %d (estimated %s for processing)":         "- **Fichiers :** %d (traitement estimé à %s)",
+		"- **Subfolders:** %d (estimated %s for processing)":    "- **Sous-dossiers :** %d (traitement estimé à %s)",
+		"- **Total estimated time for this folder:** %s":        "- **Durée totale estimée pour ce dossier :** %s",
+		"*This estimation calculates execution time based on 4 seconds per file and 7 seconds per folder for AI analysis.*": "*Cette estimation calcule la durée d'exécution sur la base de 4 secondes par fichier et 7 secondes par dossier pour l'analyse IA.*",
+
+		// Architecture report
+		"# Architecture Report":                          "# Rapport d'architecture",
+		"## Executive Summary":                           "## Synthèse",
+		"## Current Architecture":                        "## Architecture actuelle",
+		"## Findings":                                    "## Constats",
+		"| # | Priority | Severity | Finding | Effort |": "| # | Priorité | Gravité | Constat | Effort |",
+		"Severity":                                       "Gravité",
+		"Effort":                                         "Effort",
+		"Priority":                                       "Priorité",
+		"Category":                                       "Catégorie",
+		"Affected paths":                                 "Chemins concernés",
+		"Recommendation":                                 "Recommandation",
+		"## Recommended Folder Structure":                "## Structure de dossiers recommandée",
+		"## Implementation Roadmap":                      "## Feuille de route",
+	},
+}
+
+// tr returns the translation of an English markdown string, or the string
+// itself when the language has no translation for it.
+func tr(lang, s string) string {
+	if t, ok := translations[LanguageCode(lang)][s]; ok {
+		return t
+	}
+	return s
+}
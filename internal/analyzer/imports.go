@@ -0,0 +1,428 @@
+package analyzer
+
+import (
+	"bufio"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// graphSkipDirs are never scanned for imports: they hold third-party or
+// generated code that would drown the project's own dependency structure.
+var graphSkipDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+	"__pycache__":  true,
+	".venv":        true,
+	"venv":         true,
+}
+
+var (
+	jsImportRe  = regexp.MustCompile(`(?m)(?:^|[^\w.])(?:import|export)\s+(?:[\w*{}\s,$]+\s+from\s+)?['"]([^'"\n]+)['"]`)
+	jsRequireRe = regexp.MustCompile(`(?:require|import)\(\s*['"]([^'"\n]+)['"]\s*\)`)
+	pyImportRe  = regexp.MustCompile(`^\s*import\s+([\w.]+(?:\s+as\s+\w+)?(?:\s*,\s*[\w.]+(?:\s+as\s+\w+)?)*)`)
+	pyFromRe    = regexp.MustCompile(`^\s*from\s+(\.*[\w.]*)\s+import\s`)
+)
+
+// pythonStdlib lists the standard modules commonly imported by projects so
+// they don't show up as external dependencies.
+var pythonStdlib = map[string]bool{
+	"__future__": true, "abc": true, "argparse": true, "array": true, "ast": true, "asyncio": true,
+	"base64": true, "bisect": true, "builtins": true, "calendar": true, "collections": true,
+	"concurrent": true, "contextlib": true, "copy": true, "csv": true, "ctypes": true,
+	"dataclasses": true, "datetime": true, "decimal": true, "difflib": true, "email": true,
+	"enum": true, "errno": true, "fnmatch": true, "functools": true, "gc": true, "getpass": true,
+	"glob": true, "gzip": true, "hashlib": true, "heapq": true, "hmac": true, "html": true,
+	"http": true, "importlib": true, "inspect": true, "io": true, "itertools": true, "json": true,
+	"logging": true, "math": true, "mimetypes": true, "multiprocessing": true, "operator": true,
+	"os": true, "pathlib": true, "pickle": true, "platform": true, "pprint": true, "queue": true,
+	"random": true, "re": true, "secrets": true, "select": true, "shlex": true, "shutil": true,
+	"signal": true, "socket": true, "sqlite3": true, "ssl": true, "statistics": true,
+	"string": true, "struct": true, "subprocess": true, "sys": true, "tempfile": true,
+	"textwrap": true, "threading": true, "time": true, "timeit": true, "traceback": true,
+	"types": true, "typing": true, "unittest": true, "urllib": true, "uuid": true,
+	"warnings": true, "weakref": true, "xml": true, "zipfile": true, "zlib": true,
+}
+
+// graphBuilder accumulates packages and edges while walking the tree.
+type graphBuilder struct {
+	root    string
+	modules map[string]string // Go module path -> directory relative to root
+	nodes   map[string]*GraphNode
+	edges   map[[2]string]int
+}
+
+// BuildDependencyGraph scans Go, JavaScript/TypeScript and Python sources under
+// rootPath and returns the import graph between their directories.
+func (a *Analyzer) BuildDependencyGraph(rootPath string) (*DependencyGraph, error) {
+	rootPath = filepath.Clean(rootPath)
+	b := &graphBuilder{
+		root:    rootPath,
+		modules: make(map[string]string),
+		nodes:   make(map[string]*GraphNode),
+		edges:   make(map[[2]string]int),
+	}
+
+	var sources []string
+	err := filepath.Walk(rootPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if p != rootPath && graphSkipDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Name() == "go.mod" {
+			if mod := readGoModulePath(p); mod != "" {
+				b.modules[mod] = b.rel(filepath.Dir(p))
+			}
+			return nil
+		}
+		if sourceLanguage(info.Name()) != "" && info.Size() <= a.config.MaxFileSize {
+			sources = append(sources, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range sources {
+		src, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		lang := sourceLanguage(p)
+		from := b.rel(filepath.Dir(p))
+		b.addNode(from, lang, false)
+
+		var targets []string
+		switch lang {
+		case "go":
+			targets = b.goImports(p, src)
+		case "javascript", "typescript":
+			targets = b.jsImports(from, string(src))
+		case "python":
+			targets = b.pyImports(from, string(src))
+		}
+		for _, t := range targets {
+			if t != "" && t != from {
+				b.edges[[2]string{from, t}]++
+			}
+		}
+	}
+
+	return b.graph(), nil
+}
+
+func sourceLanguage(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".go":
+		return "go"
+	case ".js", ".jsx", ".mjs", ".cjs":
+		return "javascript"
+	case ".ts", ".tsx", ".mts", ".cts":
+		return "typescript"
+	case ".py":
+		return "python"
+	}
+	return ""
+}
+
+func readGoModulePath(goModPath string) string {
+	f, err := os.Open(goModPath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			mod := strings.TrimSpace(strings.TrimPrefix(line, "module "))
+			if unq, err := strconv.Unquote(mod); err == nil {
+				mod = unq
+			}
+			return mod
+		}
+	}
+	return ""
+}
+
+func (b *graphBuilder) rel(p string) string {
+	r, err := filepath.Rel(b.root, p)
+	if err != nil {
+		return filepath.ToSlash(p)
+	}
+	return filepath.ToSlash(r)
+}
+
+func (b *graphBuilder) addNode(id, lang string, external bool) string {
+	if n, ok := b.nodes[id]; ok {
+		if n.Language == "" {
+			n.Language = lang
+		}
+		return id
+	}
+	b.nodes[id] = &GraphNode{ID: id, Language: lang, External: external}
+	return id
+}
+
+// internalDir reports whether the slash-separated relative directory exists
+// inside the analyzed root.
+func (b *graphBuilder) internalDir(rel string) bool {
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(b.root, filepath.FromSlash(rel)))
+	return err == nil && info.IsDir()
+}
+
+func (b *graphBuilder) goImports(p string, src []byte) []string {
+	f, err := parser.ParseFile(token.NewFileSet(), p, src, parser.ImportsOnly)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, imp := range f.Imports {
+		ip, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		if id := b.resolveGoImport(ip); id != "" {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func (b *graphBuilder) resolveGoImport(ip string) string {
+	best := ""
+	for mod := range b.modules {
+		if (ip == mod || strings.HasPrefix(ip, mod+"/")) && len(mod) > len(best) {
+			best = mod
+		}
+	}
+	if best != "" {
+		id := path.Clean(path.Join(b.modules[best], strings.TrimPrefix(ip, best)))
+		return b.addNode(id, "go", false)
+	}
+	first := strings.SplitN(ip, "/", 2)[0]
+	if !strings.Contains(first, ".") {
+		return "" // standard library
+	}
+	parts := strings.Split(ip, "/")
+	if len(parts) > 3 {
+		parts = parts[:3]
+	}
+	return b.addNode(strings.Join(parts, "/"), "go", true)
+}
+
+func (b *graphBuilder) jsImports(from, src string) []string {
+	var out []string
+	lang := "javascript"
+	for _, re := range []*regexp.Regexp{jsImportRe, jsRequireRe} {
+		for _, m := range re.FindAllStringSubmatch(src, -1) {
+			spec := m[1]
+			if strings.HasPrefix(spec, ".") {
+				target := path.Clean(path.Join(from, spec))
+				if !b.internalDir(target) {
+					target = path.Dir(target)
+				}
+				if b.internalDir(target) {
+					out = append(out, b.addNode(target, lang, false))
+				}
+				continue
+			}
+			if strings.HasPrefix(spec, "node:") || strings.HasPrefix(spec, "/") {
+				continue
+			}
+			parts := strings.Split(spec, "/")
+			name := parts[0]
+			if strings.HasPrefix(name, "@") && len(parts) > 1 {
+				name += "/" + parts[1]
+			}
+			out = append(out, b.addNode(name, lang, true))
+		}
+	}
+	return out
+}
+
+func (b *graphBuilder) pyImports(from, src string) []string {
+	var out []string
+	for _, line := range strings.Split(src, "\n") {
+		if m := pyFromRe.FindStringSubmatch(line); m != nil {
+			out = append(out, b.resolvePyModule(from, m[1]))
+			continue
+		}
+		if m := pyImportRe.FindStringSubmatch(line); m != nil {
+			for _, mod := range strings.Split(m[1], ",") {
+				mod = strings.TrimSpace(strings.SplitN(strings.TrimSpace(mod), " ", 2)[0])
+				out = append(out, b.resolvePyModule(from, mod))
+			}
+		}
+	}
+	return out
+}
+
+func (b *graphBuilder) resolvePyModule(from, mod string) string {
+	if mod == "" {
+		return ""
+	}
+	dots := len(mod) - len(strings.TrimLeft(mod, "."))
+	modPath := strings.ReplaceAll(strings.TrimLeft(mod, "."), ".", "/")
+
+	var candidates []string
+	if dots > 0 {
+		base := from
+		for i := 1; i < dots; i++ {
+			base = path.Dir(base)
+		}
+		candidates = []string{path.Join(base, modPath)}
+	} else {
+		candidates = []string{modPath, path.Join(from, modPath), path.Join("src", modPath)}
+	}
+	for _, c := range candidates {
+		c = path.Clean(c)
+		if b.internalDir(c) {
+			return b.addNode(c, "python", false)
+		}
+		if dir := path.Dir(c); dots > 0 || dir != "." {
+			if _, err := os.Stat(filepath.Join(b.root, filepath.FromSlash(c)+".py")); err == nil {
+				return b.addNode(dir, "python", false)
+			}
+		}
+	}
+	if dots > 0 {
+		return ""
+	}
+	top := strings.SplitN(modPath, "/", 2)[0]
+	if pythonStdlib[top] {
+		return ""
+	}
+	if _, err := os.Stat(filepath.Join(b.root, top+".py")); err == nil {
+		return b.addNode(".", "python", false)
+	}
+	return b.addNode(top, "python", true)
+}
+
+func (b *graphBuilder) graph() *DependencyGraph {
+	g := &DependencyGraph{Nodes: make([]GraphNode, 0, len(b.nodes)), Edges: make([]GraphEdge, 0, len(b.edges))}
+	used := make(map[string]bool)
+	for k, count := range b.edges {
+		g.Edges = append(g.Edges, GraphEdge{From: k[0], To: k[1], Count: count})
+		used[k[0]], used[k[1]] = true, true
+	}
+	for id, n := range b.nodes {
+		if used[id] || !n.External {
+			g.Nodes = append(g.Nodes, *n)
+		}
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		if g.Nodes[i].External != g.Nodes[j].External {
+			return !g.Nodes[i].External
+		}
+		return g.Nodes[i].ID < g.Nodes[j].ID
+	})
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	return g
+}
+
+// GenerateDependencyMermaid renders the graph as a Mermaid flowchart.
+// External packages use the rounded "stadium" shape.
+func GenerateDependencyMermaid(g *DependencyGraph) string {
+	var md strings.Builder
+	md.WriteString("graph LR\n")
+	ids := make(map[string]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+		label := strings.ReplaceAll(n.ID, `"`, "'")
+		if n.External {
+			md.WriteString(fmt.Sprintf("    %s([\"%s\"])\n", ids[n.ID], label))
+		} else {
+			md.WriteString(fmt.Sprintf("    %s[\"%s\"]\n", ids[n.ID], label))
+		}
+	}
+	for _, e := range g.Edges {
+		if e.Count > 1 {
+			md.WriteString(fmt.Sprintf("    %s -->|%d| %s\n", ids[e.From], e.Count, ids[e.To]))
+		} else {
+			md.WriteString(fmt.Sprintf("    %s --> %s\n", ids[e.From], ids[e.To]))
+		}
+	}
+	return md.String()
+}
+
+// GenerateDependencyDot renders the graph in Graphviz DOT format.
+func GenerateDependencyDot(g *DependencyGraph) string {
+	var dot strings.Builder
+	dot.WriteString("digraph dependencies {\n")
+	dot.WriteString("    rankdir=LR;\n")
+	dot.WriteString("    node [shape=box, fontname=\"Helvetica\"];\n")
+	for _, n := range g.Nodes {
+		if n.External {
+			dot.WriteString(fmt.Sprintf("    %s [style=dashed];\n", strconv.Quote(n.ID)))
+		} else {
+			dot.WriteString(fmt.Sprintf("    %s;\n", strconv.Quote(n.ID)))
+		}
+	}
+	for _, e := range g.Edges {
+		dot.WriteString(fmt.Sprintf("    %s -> %s", strconv.Quote(e.From), strconv.Quote(e.To)))
+		if e.Count > 1 {
+			dot.WriteString(fmt.Sprintf(" [label=\"%d\"]", e.Count))
+		}
+		dot.WriteString(";\n")
+	}
+	dot.WriteString("}\n")
+	return dot.String()
+}
+
+// FormatDependencyEdges returns a condensed "from -> to (count)" listing for
+// prompts. Internal edges come first, heaviest first; at most maxEdges lines
+// are written.
+func FormatDependencyEdges(g *DependencyGraph, maxEdges int) string {
+	if g == nil || len(g.Edges) == 0 {
+		return ""
+	}
+	external := make(map[string]bool)
+	for _, n := range g.Nodes {
+		external[n.ID] = n.External
+	}
+	edges := append([]GraphEdge(nil), g.Edges...)
+	sort.SliceStable(edges, func(i, j int) bool {
+		ei, ej := external[edges[i].To], external[edges[j].To]
+		if ei != ej {
+			return !ei
+		}
+		return edges[i].Count > edges[j].Count
+	})
+
+	var sb strings.Builder
+	for i, e := range edges {
+		if i == maxEdges {
+			sb.WriteString(fmt.Sprintf("... and %d more edges\n", len(edges)-maxEdges))
+			break
+		}
+		suffix := ""
+		if external[e.To] {
+			suffix = " [external]"
+		}
+		sb.WriteString(fmt.Sprintf("%s -> %s (%d)%s\n", e.From, e.To, e.Count, suffix))
+	}
+	return sb.String()
+}
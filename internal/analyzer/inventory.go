@@ -0,0 +1,240 @@
+package analyzer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Inventory gathers the statistics of a flat analysis, computed from the
+// metadata of the tree.
+type Inventory struct {
+	Files          int              `json:"files"`
+	Folders        int              `json:"folders"`
+	TotalSize      int64            `json:"totalSize"`
+	TotalLines     int              `json:"totalLines"`
+	Types          []InventoryType  `json:"types"`
+	LargestFiles   []InventoryEntry `json:"largestFiles"`
+	LargestFolders []InventoryEntry `json:"largestFolders"`
+	// EmptyFolders lists the folders with no file below them, without their
+	// empty subfolders
+	EmptyFolders []string `json:"emptyFolders,omitempty"`
+}
+
+// InventoryType aggregates the files of one extension.
+type InventoryType struct {
+	Extension string `json:"extension"`
+	Language  string `json:"language,omitempty"`
+	Files     int    `json:"files"`
+	Size      int64  `json:"size"`
+	Lines     int    `json:"lines,omitempty"`
+}
+
+// InventoryEntry is a file or folder of the size rankings.
+type InventoryEntry struct {
+	Path  string `json:"path"`
+	Size  int64  `json:"size"`
+	Lines int    `json:"lines,omitempty"`
+	Files int    `json:"files,omitempty"`
+}
+
+// IsFlatMode reports whether mode is "flat", which analyzes the tree locally
+// without calling the AI service.
+func IsFlatMode(mode string) bool {
+	return strings.ToLower(strings.TrimSpace(mode)) == "flat"
+}
+
+// PerformFlatAnalysis walks the tree and collects what can be computed
+// locally: metadata, git history, licenses, duplicates and near duplicates,
+// plus the heuristic security findings with the security profile. Nodes get
+// no description. Metadata is collected even when disabled in the
+// configuration, since the inventory and the duplicates rely on it.
+func (a *Analyzer) PerformFlatAnalysis(rootPath string) (*Node, error) {
+	rootPath = filepath.Clean(rootPath)
+	cfg := *a.config
+	cfg.Metadata.Enabled = true
+	local := &Analyzer{config: &cfg}
+	if IsSecurityProfile(cfg.AnalysisProfile) {
+		// The heuristics scan the redacted content
+		if err := a.loadPIIFilter(); err != nil {
+			return nil, err
+		}
+	}
+
+	rootNode, fileNodes, err := local.buildTree(rootPath, false)
+	if err != nil {
+		return nil, err
+	}
+	local.collectMetadata(rootPath, fileNodes)
+	local.collectHistory(rootPath, rootNode, fileNodes)
+	aggregateMetadata(rootNode)
+	local.detectLicenses(rootNode, fileNodes)
+	unique := local.markDuplicates(fileNodes)
+	local.findNearDuplicates(unique)
+	if IsSecurityProfile(cfg.AnalysisProfile) {
+		local.secrets, local.pii = a.secrets, a.pii
+		local.scanSecurityLocally(unique)
+		shareDuplicateDescriptions(fileNodes)
+	}
+	return rootNode, nil
+}
+
+// BuildInventory computes the statistics of the tree, listing up to limit
+// files and folders in the size rankings.
+func BuildInventory(root *Node, limit int) *Inventory {
+	inv := &Inventory{}
+	if root == nil {
+		return inv
+	}
+	types := make(map[string]*InventoryType)
+	var files, folders []InventoryEntry
+	// walk returns the number of files below n
+	var walk func(n *Node) int
+	walk = func(n *Node) int {
+		if n.Type != "directory" {
+			inv.Files++
+			e := InventoryEntry{Path: displayPath(root, n)}
+			if n.Metadata != nil {
+				e.Size, e.Lines = n.Metadata.Size, n.Metadata.Lines
+			}
+			inv.TotalSize += e.Size
+			inv.TotalLines += e.Lines
+			files = append(files, e)
+
+			ext := strings.ToLower(filepath.Ext(n.Name))
+			if ext == "" {
+				ext = "no extension"
+			}
+			t := types[ext]
+			if t == nil {
+				t = &InventoryType{Extension: ext, Language: detectLanguage(n.Name)}
+				types[ext] = t
+			}
+			t.Files++
+			t.Size += e.Size
+			t.Lines += e.Lines
+			return 1
+		}
+
+		if n != root {
+			inv.Folders++
+		}
+		count := 0
+		for _, ch := range n.Children {
+			count += walk(ch)
+		}
+		if n != root {
+			e := InventoryEntry{Path: displayPath(root, n), Files: count}
+			if n.Metadata != nil {
+				e.Size = n.Metadata.Size
+			}
+			folders = append(folders, e)
+		}
+		return count
+	}
+	walk(root)
+
+	for _, t := range types {
+		inv.Types = append(inv.Types, *t)
+	}
+	sort.Slice(inv.Types, func(i, j int) bool {
+		if inv.Types[i].Files != inv.Types[j].Files {
+			return inv.Types[i].Files > inv.Types[j].Files
+		}
+		return inv.Types[i].Extension < inv.Types[j].Extension
+	})
+	inv.LargestFiles = largestEntries(files, limit)
+	inv.LargestFolders = largestEntries(folders, limit)
+	inv.EmptyFolders = emptyFolders(root)
+	return inv
+}
+
+func largestEntries(entries []InventoryEntry, limit int) []InventoryEntry {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Size > entries[j].Size })
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// emptyFolders lists the topmost folders with no file below them.
+func emptyFolders(root *Node) []string {
+	var empty []string
+	var hasFiles func(n *Node) bool
+	hasFiles = func(n *Node) bool {
+		if n.Type != "directory" {
+			return true
+		}
+		for _, ch := range n.Children {
+			if hasFiles(ch) {
+				return true
+			}
+		}
+		return false
+	}
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		for _, ch := range n.Children {
+			if ch.Type != "directory" {
+				continue
+			}
+			if hasFiles(ch) {
+				walk(ch)
+			} else {
+				empty = append(empty, displayPath(root, ch))
+			}
+		}
+	}
+	walk(root)
+	return empty
+}
+
+// generateInventorySection renders the statistics of a flat analysis.
+func generateInventorySection(inv *Inventory, lang string) string {
+	if inv == nil {
+		return ""
+	}
+	var md strings.Builder
+	md.WriteString(tr(lang, "## Inventory") + "\n\n")
+	md.WriteString(fmt.Sprintf(tr(lang, "%d files in %d folders, %s, %d lines of text.")+"\n\n", inv.Files, inv.Folders, formatBytes(inv.TotalSize), inv.TotalLines))
+
+	if len(inv.Types) > 0 {
+		md.WriteString(tr(lang, "### File Types") + "\n\n")
+		md.WriteString(tr(lang, "| Extension | Language | Files | Size | Lines |") + "\n")
+		md.WriteString("|---|---|---:|---:|---:|\n")
+		for _, t := range inv.Types {
+			md.WriteString(fmt.Sprintf("| `%s` | %s | %d | %s | %d |\n", escapeTableCell(t.Extension), t.Language, t.Files, formatBytes(t.Size), t.Lines))
+		}
+		md.WriteString("\n")
+	}
+
+	if len(inv.LargestFiles) > 0 {
+		md.WriteString(tr(lang, "### Largest Files") + "\n\n")
+		md.WriteString(tr(lang, "| File | Size | Lines |") + "\n")
+		md.WriteString("|---|---:|---:|\n")
+		for _, e := range inv.LargestFiles {
+			md.WriteString(fmt.Sprintf("| `%s` | %s | %d |\n", escapeTableCell(e.Path), formatBytes(e.Size), e.Lines))
+		}
+		md.WriteString("\n")
+	}
+
+	if len(inv.LargestFolders) > 0 {
+		md.WriteString(tr(lang, "### Largest Folders") + "\n\n")
+		md.WriteString(tr(lang, "| Folder | Size | Files |") + "\n")
+		md.WriteString("|---|---:|---:|\n")
+		for _, e := range inv.LargestFolders {
+			md.WriteString(fmt.Sprintf("| `%s/` | %s | %d |\n", escapeTableCell(e.Path), formatBytes(e.Size), e.Files))
+		}
+		md.WriteString("\n")
+	}
+
+	if len(inv.EmptyFolders) > 0 {
+		md.WriteString(tr(lang, "### Empty Folders") + "\n\n")
+		for _, p := range inv.EmptyFolders {
+			md.WriteString(fmt.Sprintf("- `%s/`\n", p))
+		}
+		md.WriteString("\n")
+	}
+	return md.String()
+}
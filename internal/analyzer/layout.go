@@ -0,0 +1,299 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TreePaths lists every node of the tree as a path relative to the root, one
+// per line, folders suffixed with "/".
+func TreePaths(root *Node) string {
+	var sb strings.Builder
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if n != root {
+			sb.WriteString(relPath(root, n))
+			if n.Type == "directory" {
+				sb.WriteString("/")
+			}
+			sb.WriteString("\n")
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+	return sb.String()
+}
+
+// relPath returns the slash-separated path of n relative to root ("." for
+// the root itself).
+func relPath(root, n *Node) string {
+	rel, err := filepath.Rel(root.Path, n.Path)
+	if err != nil {
+		return filepath.ToSlash(n.Path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// cleanLayoutPath normalizes a model-provided path and rejects paths that
+// would escape the project root.
+func cleanLayoutPath(p string) (string, bool) {
+	p = strings.TrimSuffix(strings.TrimSpace(filepath.ToSlash(p)), "/")
+	p = path.Clean(strings.TrimPrefix(p, "./"))
+	if p == "" || p == "." || path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../") {
+		return "", false
+	}
+	return p, true
+}
+
+// ComputeMovePlan compares the current tree with the target layout and
+// returns the folders to create and the moves to perform. Entries that cannot
+// be applied safely are reported as warnings and left out of the plan.
+func ComputeMovePlan(root *Node, layout *TargetLayout) *MovePlan {
+	plan := &MovePlan{Root: root.Path}
+
+	existing := make(map[string]*Node)
+	var index func(n *Node)
+	index = func(n *Node) {
+		existing[relPath(root, n)] = n
+		for _, child := range n.Children {
+			index(child)
+		}
+	}
+	index(root)
+
+	isDir := func(p string) bool {
+		n, ok := existing[p]
+		return ok && n.Type == "directory"
+	}
+	within := func(p, dir string) bool {
+		return p == dir || strings.HasPrefix(p, dir+"/")
+	}
+
+	create := make(map[string]bool)
+	sources := make(map[string]bool)
+	destinations := make(map[string]string)
+
+	for _, folder := range layout.Folders {
+		target, ok := cleanLayoutPath(folder.Path)
+		if !ok {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("ignoring folder %q: path must be relative to the project root", folder.Path))
+			continue
+		}
+		if n, ok := existing[target]; ok && n.Type != "directory" {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("ignoring folder %q: a file already exists at that path", target))
+			continue
+		}
+		if !isDir(target) {
+			create[target] = true
+		}
+
+		for _, item := range folder.Contents {
+			from, ok := cleanLayoutPath(item)
+			if !ok {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("ignoring %q: path must be relative to the project root", item))
+				continue
+			}
+			if _, ok := existing[from]; !ok {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("ignoring %q: not found in the analyzed tree", from))
+				continue
+			}
+			to := path.Join(target, path.Base(from))
+			if to == from {
+				continue
+			}
+			if within(target, from) {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("ignoring %q: cannot move a folder into itself (%s)", from, target))
+				continue
+			}
+			if sources[from] {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("ignoring %q: already assigned to another folder", from))
+				continue
+			}
+			if other, ok := destinations[to]; ok {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("ignoring %q: %s is already the destination of %s", from, to, other))
+				continue
+			}
+			if _, ok := existing[to]; ok {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("ignoring %q: %s already exists", from, to))
+				continue
+			}
+			sources[from] = true
+			destinations[to] = from
+			plan.Moves = append(plan.Moves, Move{From: from, To: to})
+		}
+	}
+
+	// A path moved together with one of its ancestors must not be moved again
+	var moves []Move
+	for _, m := range plan.Moves {
+		nested := false
+		for other := range sources {
+			if other != m.From && within(m.From, other) {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("ignoring %q: its parent %s is already moved", m.From, other))
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			moves = append(moves, m)
+		}
+	}
+
+	// A destination inside a folder that is itself moved would end up at the
+	// old or the new location depending on the order of the moves
+	moved := make(map[string]bool, len(moves))
+	for _, m := range moves {
+		moved[m.From] = true
+	}
+	movedAncestor := func(p string) string {
+		for src := range moved {
+			if within(p, src) {
+				return src
+			}
+		}
+		return ""
+	}
+	plan.Moves = nil
+	for _, m := range moves {
+		if src := movedAncestor(path.Dir(m.To)); src != "" {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("ignoring %q: its destination %s is inside %s, which is moved", m.From, m.To, src))
+			continue
+		}
+		plan.Moves = append(plan.Moves, m)
+	}
+
+	dirs := make([]string, 0, len(create))
+	for dir := range create {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		if src := movedAncestor(dir); src != "" {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("ignoring folder %q: it is inside %s, which is moved", dir, src))
+			continue
+		}
+		plan.CreateDirs = append(plan.CreateDirs, dir)
+	}
+	return plan
+}
+
+// FormatMovePlan renders the plan as a human-readable dry run.
+func FormatMovePlan(plan *MovePlan) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Root: %s\n\n", plan.Root))
+	sb.WriteString(fmt.Sprintf("Folders to create (%d):\n", len(plan.CreateDirs)))
+	for _, d := range plan.CreateDirs {
+		sb.WriteString(fmt.Sprintf("  + %s/\n", d))
+	}
+	sb.WriteString(fmt.Sprintf("\nMoves (%d):\n", len(plan.Moves)))
+	for _, m := range plan.Moves {
+		sb.WriteString(fmt.Sprintf("  %s -> %s\n", m.From, m.To))
+	}
+	if len(plan.Warnings) > 0 {
+		sb.WriteString(fmt.Sprintf("\nSkipped (%d):\n", len(plan.Warnings)))
+		for _, w := range plan.Warnings {
+			sb.WriteString(fmt.Sprintf("  ! %s\n", w))
+		}
+	}
+	return sb.String()
+}
+
+// GenerateGitMoveScript renders the plan as a POSIX shell script using
+// "git mv", to be reviewed and run from the project root.
+func GenerateGitMoveScript(plan *MovePlan) string {
+	var sb strings.Builder
+	sb.WriteString("#!/bin/sh\n")
+	sb.WriteString("# Generated by archi: applies the recommended folder structure.\n")
+	sb.WriteString("# Run from the project root.\n")
+	sb.WriteString("set -e\n\n")
+	created := make(map[string]bool)
+	for _, d := range plan.CreateDirs {
+		sb.WriteString(fmt.Sprintf("mkdir -p %s\n", shellQuote(d)))
+		created[d] = true
+	}
+	if len(plan.CreateDirs) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, m := range plan.Moves {
+		if dir := path.Dir(m.To); dir != "." && !created[dir] {
+			sb.WriteString(fmt.Sprintf("mkdir -p %s\n", shellQuote(dir)))
+			created[dir] = true
+		}
+		sb.WriteString(fmt.Sprintf("git mv %s %s\n", shellQuote(m.From), shellQuote(m.To)))
+	}
+	return sb.String()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ApplyMovePlan creates the planned folders and performs the moves under
+// plan.Root. If any step fails, every completed step is undone in reverse
+// order before the error is returned; a step that cannot be undone does not
+// stop the others, and all the undo errors are reported.
+func ApplyMovePlan(plan *MovePlan) error {
+	var undo []func() error
+	rollback := func(cause error) error {
+		var failed []string
+		for i := len(undo) - 1; i >= 0; i-- {
+			if err := undo[i](); err != nil {
+				failed = append(failed, err.Error())
+			}
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("%v (rollback incomplete: %s)", cause, strings.Join(failed, "; "))
+		}
+		return fmt.Errorf("%v (all changes rolled back)", cause)
+	}
+
+	mkdirAll := func(rel string) error {
+		// Create missing levels one at a time so each can be removed on rollback
+		var missing []string
+		for dir := filepath.Join(plan.Root, filepath.FromSlash(rel)); ; dir = filepath.Dir(dir) {
+			if _, err := os.Stat(dir); err == nil {
+				break
+			}
+			missing = append(missing, dir)
+			if dir == filepath.Dir(dir) {
+				break
+			}
+		}
+		for i := len(missing) - 1; i >= 0; i-- {
+			dir := missing[i]
+			if err := os.Mkdir(dir, 0755); err != nil {
+				return fmt.Errorf("error creating %s: %w", dir, err)
+			}
+			undo = append(undo, func() error { return os.Remove(dir) })
+		}
+		return nil
+	}
+
+	for _, d := range plan.CreateDirs {
+		if err := mkdirAll(d); err != nil {
+			return rollback(err)
+		}
+	}
+
+	for _, m := range plan.Moves {
+		from := filepath.Join(plan.Root, filepath.FromSlash(m.From))
+		to := filepath.Join(plan.Root, filepath.FromSlash(m.To))
+		if err := mkdirAll(path.Dir(m.To)); err != nil {
+			return rollback(err)
+		}
+		if _, err := os.Lstat(to); err == nil {
+			return rollback(fmt.Errorf("cannot move %s: %s already exists", m.From, m.To))
+		}
+		if err := os.Rename(from, to); err != nil {
+			return rollback(fmt.Errorf("error moving %s to %s: %w", m.From, m.To, err))
+		}
+		undo = append(undo, func() error { return os.Rename(to, from) })
+	}
+	return nil
+}
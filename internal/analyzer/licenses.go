@@ -0,0 +1,509 @@
+package analyzer
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// licenseTexts are the bundled license texts, named by SPDX identifier. Long
+// licenses keep only their opening sections, which are enough to tell them
+// apart.
+//
+//go:embed licenses/*.txt
+var licenseTexts embed.FS
+
+// Kinds of license, by the obligations they put on combined works.
+const (
+	licensePermissive     = "permissive"
+	licenseWeakCopyleft   = "weak copyleft"
+	licenseStrongCopyleft = "strong copyleft"
+)
+
+// licenseUnknown is recorded for license files matching no bundled text.
+const licenseUnknown = "NOASSERTION"
+
+// knownLicenses names the licenses recognized in SPDX headers and license
+// files, with their kind. The GNU licenses without -only or -or-later are the
+// texts matched in license files, which do not say whether later versions
+// apply.
+var knownLicenses = map[string]struct{ name, kind string }{
+	"MIT":               {"MIT License", licensePermissive},
+	"ISC":               {"ISC License", licensePermissive},
+	"0BSD":              {"BSD Zero Clause License", licensePermissive},
+	"BSD-2-Clause":      {"BSD 2-Clause License", licensePermissive},
+	"BSD-3-Clause":      {"BSD 3-Clause License", licensePermissive},
+	"Apache-2.0":        {"Apache License 2.0", licensePermissive},
+	"Unlicense":         {"The Unlicense", licensePermissive},
+	"BSL-1.0":           {"Boost Software License 1.0", licensePermissive},
+	"MPL-2.0":           {"Mozilla Public License 2.0", licenseWeakCopyleft},
+	"LGPL-2.1":          {"GNU Lesser General Public License v2.1, version terms not stated", licenseWeakCopyleft},
+	"LGPL-3.0":          {"GNU Lesser General Public License v3.0, version terms not stated", licenseWeakCopyleft},
+	"GPL-2.0":           {"GNU General Public License v2.0, version terms not stated", licenseStrongCopyleft},
+	"GPL-3.0":           {"GNU General Public License v3.0, version terms not stated", licenseStrongCopyleft},
+	"AGPL-3.0":          {"GNU Affero General Public License v3.0, version terms not stated", licenseStrongCopyleft},
+	"LGPL-2.1-only":     {"GNU Lesser General Public License v2.1 only", licenseWeakCopyleft},
+	"LGPL-2.1-or-later": {"GNU Lesser General Public License v2.1 or later", licenseWeakCopyleft},
+	"LGPL-3.0-only":     {"GNU Lesser General Public License v3.0 only", licenseWeakCopyleft},
+	"LGPL-3.0-or-later": {"GNU Lesser General Public License v3.0 or later", licenseWeakCopyleft},
+	"GPL-2.0-only":      {"GNU General Public License v2.0 only", licenseStrongCopyleft},
+	"GPL-2.0-or-later":  {"GNU General Public License v2.0 or later", licenseStrongCopyleft},
+	"GPL-3.0-only":      {"GNU General Public License v3.0 only", licenseStrongCopyleft},
+	"GPL-3.0-or-later":  {"GNU General Public License v3.0 or later", licenseStrongCopyleft},
+	"AGPL-3.0-only":     {"GNU Affero General Public License v3.0 only", licenseStrongCopyleft},
+	"AGPL-3.0-or-later": {"GNU Affero General Public License v3.0 or later", licenseStrongCopyleft},
+	licenseUnknown:      {"Unrecognized license", ""},
+}
+
+// deprecatedLicenseIDs maps the SPDX identifiers replaced in version 3 of the
+// license list to the current ones. They apply to SPDX headers only: the
+// bare identifiers name the GNU license texts matched in license files.
+var deprecatedLicenseIDs = map[string]string{
+	"GPL-2.0": "GPL-2.0-only", "GPL-2.0+": "GPL-2.0-or-later",
+	"GPL-3.0": "GPL-3.0-only", "GPL-3.0+": "GPL-3.0-or-later",
+	"LGPL-2.1": "LGPL-2.1-only", "LGPL-2.1+": "LGPL-2.1-or-later",
+	"LGPL-3.0": "LGPL-3.0-only", "LGPL-3.0+": "LGPL-3.0-or-later",
+	"AGPL-3.0": "AGPL-3.0-only", "AGPL-3.0+": "AGPL-3.0-or-later",
+}
+
+// licenseName returns the full name of a license, or its identifier when it
+// is not known.
+func licenseName(id string) string {
+	if l, ok := knownLicenses[id]; ok {
+		return l.name
+	}
+	return id
+}
+
+var (
+	licenseFileRe = regexp.MustCompile(`(?i)^(?:(?:un)?licen[cs]e|copying)(?:[-._].*)?$|^[\w.-]+[-_.]licen[cs]e(?:\.\w+)?$`)
+	// An expression ending the line or a comment
+	spdxHeaderRe = regexp.MustCompile(`(?m)SPDX-License-Identifier:[ \t]*([A-Za-z0-9.+() -]+?)[ \t]*(?:\*/|-->|#\}|\*\)|$)`)
+	// Copyright lines differ from one copy of a license to another
+	copyrightLineRe = regexp.MustCompile(`(?im)^\W*copyright\s+(?:\(c\)|©|\d{4}).*$`)
+	licenseWordRe   = regexp.MustCompile(`[a-z0-9]+`)
+)
+
+// SPDX headers are searched in the first spdxHeaderLines lines of a file,
+// within its first spdxHeaderBytes bytes.
+const (
+	spdxHeaderLines = 30
+	spdxHeaderBytes = 8192
+)
+
+// licenseTemplate is a bundled license text as a set of word trigrams.
+type licenseTemplate struct {
+	id       string
+	shingles map[string]bool
+}
+
+var (
+	licenseTemplatesOnce sync.Once
+	licenseTemplates     []licenseTemplate
+)
+
+func loadLicenseTemplates() []licenseTemplate {
+	licenseTemplatesOnce.Do(func() {
+		entries, _ := licenseTexts.ReadDir("licenses")
+		for _, e := range entries {
+			data, err := licenseTexts.ReadFile("licenses/" + e.Name())
+			if err != nil {
+				continue
+			}
+			id := strings.TrimSuffix(e.Name(), ".txt")
+			licenseTemplates = append(licenseTemplates, licenseTemplate{id: id, shingles: licenseShingles(string(data))})
+		}
+	})
+	return licenseTemplates
+}
+
+// licenseShingles returns the word trigrams of a license text, without its
+// copyright lines, punctuation and case.
+func licenseShingles(text string) map[string]bool {
+	text = copyrightLineRe.ReplaceAllString(text, "")
+	words := licenseWordRe.FindAllString(strings.ToLower(text), -1)
+	shingles := make(map[string]bool)
+	for i := 0; i+3 <= len(words); i++ {
+		shingles[strings.Join(words[i:i+3], " ")] = true
+	}
+	return shingles
+}
+
+// MatchLicenseText returns the bundled license whose text is contained in
+// text, at least threshold (0-1) of its word trigrams being found, or "" when
+// none is. Of several matches (a BSD-3-Clause text also contains the
+// BSD-2-Clause one), the license with the most trigrams found wins.
+func MatchLicenseText(text string, threshold float64) string {
+	shingles := licenseShingles(text)
+	best, bestFound := "", 0
+	for _, t := range loadLicenseTemplates() {
+		found := 0
+		for s := range t.shingles {
+			if shingles[s] {
+				found++
+			}
+		}
+		if len(t.shingles) > 0 && float64(found)/float64(len(t.shingles)) >= threshold && found > bestFound {
+			best, bestFound = t.id, found
+		}
+	}
+	return best
+}
+
+// isLicenseFile reports whether a file holds a license text, from its name:
+// LICENSE, COPYING.LESSER, LICENSE-MIT.md or MIT-LICENSE.txt, but not
+// license.go.
+func isLicenseFile(name string) bool {
+	if !licenseFileRe.MatchString(name) {
+		return false
+	}
+	switch detectLanguage(name) {
+	case "", "Text", "Markdown", "reStructuredText":
+		return true
+	}
+	return false
+}
+
+// spdxExpression returns the license expression of the SPDX header in the
+// first lines of text.
+func spdxExpression(text string) string {
+	lines := strings.SplitN(text, "\n", spdxHeaderLines+1)
+	if len(lines) > spdxHeaderLines {
+		lines = lines[:spdxHeaderLines]
+	}
+	m := spdxHeaderRe.FindStringSubmatch(strings.Join(lines, "\n"))
+	if m == nil {
+		return ""
+	}
+	return strings.TrimSpace(m[1])
+}
+
+// licenseIDs returns the license identifiers of an SPDX expression, in
+// their current form and without the exceptions named after WITH.
+func licenseIDs(expr string) []string {
+	var ids []string
+	fields := strings.Fields(strings.NewReplacer("(", " ", ")", " ").Replace(expr))
+	for i := 0; i < len(fields); i++ {
+		switch strings.ToUpper(fields[i]) {
+		case "AND", "OR":
+			continue
+		case "WITH":
+			i++
+			continue
+		}
+		id := fields[i]
+		if current, ok := deprecatedLicenseIDs[id]; ok {
+			id = current
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// isLicenseChoice reports whether an expression offers a choice of licenses,
+// e.g. "MIT OR Apache-2.0", which cannot conflict.
+func isLicenseChoice(expr string) bool {
+	return strings.Contains(strings.ToUpper(expr), " OR ")
+}
+
+// detectLicenses records the license of the license files (LICENSE,
+// COPYING, ...) and of the files with an SPDX header, then gives each folder
+// the license of its own license files, or else of its parent, and the list
+// of the licenses found below it.
+func (a *Analyzer) detectLicenses(rootNode *Node, fileNodes []*Node) {
+	cfg := a.config.Licenses
+	if !cfg.Enabled || rootNode == nil {
+		return
+	}
+	found := 0
+	for _, n := range fileNodes {
+		if n.License = detectFileLicense(n.Path, n.Name, cfg.Threshold); n.License != "" {
+			found++
+		}
+	}
+	propagateLicenses(rootNode, "")
+	if found > 0 {
+		fmt.Printf("⚖️  Found license information in %d files\n", found)
+	}
+}
+
+// detectFileLicense matches a license file against the bundled texts and
+// reads the SPDX header of other files.
+func detectFileLicense(filePath, name string, threshold float64) string {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	if isLicenseFile(name) {
+		data, err := io.ReadAll(io.LimitReader(f, 1<<20))
+		if err != nil {
+			return ""
+		}
+		if id := MatchLicenseText(string(data), threshold); id != "" {
+			return id
+		}
+		if expr := spdxExpression(string(data)); expr != "" {
+			return expr
+		}
+		return licenseUnknown
+	}
+
+	head := make([]byte, spdxHeaderBytes)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+	if strings.IndexByte(string(head), 0) >= 0 {
+		return "" // binary
+	}
+	return spdxExpression(string(head))
+}
+
+// propagateLicenses sets the License of the folders below n, inherited being
+// the license of the parent folder, and their Licenses; it returns the
+// licenses found below n.
+func propagateLicenses(n *Node, inherited string) []string {
+	if n.Type != "directory" {
+		if n.License == "" {
+			return nil
+		}
+		return licenseIDs(n.License)
+	}
+
+	var own []string
+	for _, ch := range n.Children {
+		if ch.Type != "directory" && ch.License != "" && isLicenseFile(ch.Name) {
+			own = appendUnique(own, ch.License)
+		}
+	}
+	n.License = inherited
+	if len(own) > 0 {
+		// Every license file of a folder applies to it
+		n.License = strings.Join(own, " AND ")
+	}
+
+	var below []string
+	for _, ch := range n.Children {
+		for _, id := range propagateLicenses(ch, n.License) {
+			below = appendUnique(below, id)
+		}
+	}
+	sort.Strings(below)
+	n.Licenses = below
+	return below
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// LicenseSummary is the license inventory of a tree.
+type LicenseSummary struct {
+	// License of the root folder, from its license files
+	Project   string            `json:"project,omitempty"`
+	Licenses  []LicenseUsage    `json:"licenses"`
+	Conflicts []LicenseConflict `json:"conflicts,omitempty"`
+}
+
+// LicenseUsage lists where a license is declared.
+type LicenseUsage struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Kind string `json:"kind,omitempty"`
+	// LicenseFiles are the LICENSE, COPYING, ... files with this license
+	LicenseFiles []string `json:"licenseFiles,omitempty"`
+	// HeaderFiles counts the files declaring it in an SPDX header
+	HeaderFiles int `json:"headerFiles"`
+}
+
+// LicenseConflict is a combination of licenses that cannot be distributed
+// together.
+type LicenseConflict struct {
+	Licenses []string `json:"licenses"`
+	Reason   string   `json:"reason"`
+	// Paths are some of the files declaring the licenses
+	Paths []string `json:"paths"`
+}
+
+// maxConflictPaths is the number of example paths given per conflict.
+const maxConflictPaths = 5
+
+// SummarizeLicenses lists the licenses declared in the tree, the most used
+// first, and flags the incompatible combinations. It returns nil when no
+// license was found.
+func SummarizeLicenses(root *Node) *LicenseSummary {
+	if root == nil {
+		return nil
+	}
+	usages := make(map[string]*LicenseUsage)
+	// paths of the files declaring each license, choices left out
+	declared := make(map[string][]string)
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if n.Type != "directory" && n.License != "" && n.ChangeStatus != StatusDeleted {
+			p := displayPath(root, n)
+			isFile := isLicenseFile(n.Name)
+			for _, id := range licenseIDs(n.License) {
+				u := usages[id]
+				if u == nil {
+					u = &LicenseUsage{ID: id, Name: licenseName(id), Kind: knownLicenses[id].kind}
+					usages[id] = u
+				}
+				if isFile {
+					u.LicenseFiles = append(u.LicenseFiles, p)
+				} else {
+					u.HeaderFiles++
+				}
+				if !isLicenseChoice(n.License) {
+					declared[id] = append(declared[id], p)
+				}
+			}
+		}
+		for _, ch := range n.Children {
+			walk(ch)
+		}
+	}
+	walk(root)
+	if len(usages) == 0 {
+		return nil
+	}
+
+	summary := &LicenseSummary{}
+	if root.Type == "directory" {
+		summary.Project = root.License
+	}
+	for _, u := range usages {
+		summary.Licenses = append(summary.Licenses, *u)
+	}
+	sort.Slice(summary.Licenses, func(i, j int) bool {
+		a, b := summary.Licenses[i], summary.Licenses[j]
+		if ua, ub := len(a.LicenseFiles)+a.HeaderFiles, len(b.LicenseFiles)+b.HeaderFiles; ua != ub {
+			return ua > ub
+		}
+		return a.ID < b.ID
+	})
+	summary.Conflicts = licenseConflicts(summary.Project, declared)
+	return summary
+}
+
+// licenseConflicts flags the pairs of declared licenses that are known to be
+// incompatible, and the strong copyleft code of a project distributed under
+// another license.
+func licenseConflicts(project string, declared map[string][]string) []LicenseConflict {
+	ids := make([]string, 0, len(declared))
+	for id := range declared {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var conflicts []LicenseConflict
+	add := func(a, b, reason string) {
+		paths := append(append([]string(nil), declared[a]...), declared[b]...)
+		if len(paths) > maxConflictPaths {
+			paths = paths[:maxConflictPaths]
+		}
+		conflicts = append(conflicts, LicenseConflict{Licenses: []string{a, b}, Reason: reason, Paths: paths})
+	}
+	for i, a := range ids {
+		for _, b := range ids[i+1:] {
+			if reason := incompatibility(a, b); reason != "" {
+				add(a, b, reason)
+			} else if reason := incompatibility(b, a); reason != "" {
+				add(b, a, reason)
+			}
+		}
+	}
+
+	projectIDs := licenseIDs(project)
+	if isLicenseChoice(project) || len(projectIDs) == 0 {
+		return conflicts
+	}
+	for _, id := range ids {
+		if knownLicenses[id].kind != licenseStrongCopyleft {
+			continue
+		}
+		for _, p := range projectIDs {
+			if p != id && knownLicenses[p].kind != licenseStrongCopyleft && p != licenseUnknown {
+				add(id, p, fmt.Sprintf("strong copyleft code in a project distributed under %s: the combined work can only be distributed under %s", p, id))
+			}
+		}
+	}
+	return conflicts
+}
+
+// incompatibility returns why code under a cannot be combined with code
+// under b, or "" when the combination is not known to be a problem. The
+// version-specific conflicts need identifiers stating the version terms, as
+// SPDX headers do: a GNU license text alone lets the recipient choose any
+// version.
+func incompatibility(a, b string) string {
+	switch {
+	case a == "GPL-2.0-only" && b == "Apache-2.0":
+		return "the patent termination and indemnity terms of Apache-2.0 are restrictions that GPL version 2 does not allow"
+	case a == "GPL-2.0-only" && isLicenseV3(b):
+		return "GPL-2.0-only code cannot be distributed under the terms of a version 3 GNU license"
+	case a == "LGPL-2.1-only" && (b == "GPL-3.0-only" || (strings.HasPrefix(b, "AGPL-3.0") && isLicenseV3(b))):
+		return "LGPL-2.1-only code can only be combined with GPL version 2 code"
+	}
+	return ""
+}
+
+// isLicenseV3 reports whether id is a version 3 GNU license whose version
+// terms are stated, -only or -or-later.
+func isLicenseV3(id string) bool {
+	for _, prefix := range []string{"GPL-3.0-", "LGPL-3.0-", "AGPL-3.0-"} {
+		if strings.HasPrefix(id, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// generateLicensesSection renders the license inventory.
+func generateLicensesSection(s *LicenseSummary, lang string) string {
+	if s == nil {
+		return ""
+	}
+	var md strings.Builder
+	md.WriteString(tr(lang, "## Licenses") + "\n\n")
+	if s.Project != "" {
+		md.WriteString(fmt.Sprintf(tr(lang, "Project license: %s.")+"\n\n", s.Project))
+	}
+	md.WriteString(tr(lang, "| License | Kind | License files | Files with an SPDX header |") + "\n")
+	md.WriteString("|---|---|---|---:|\n")
+	for _, u := range s.Licenses {
+		files := make([]string, len(u.LicenseFiles))
+		for i, f := range u.LicenseFiles {
+			files[i] = "`" + f + "`"
+		}
+		kind := ""
+		if u.Kind != "" {
+			kind = tr(lang, u.Kind)
+		}
+		md.WriteString(fmt.Sprintf("| %s (%s) | %s | %s | %d |\n", escapeTableCell(u.Name), escapeTableCell(u.ID), kind, escapeTableCell(strings.Join(files, ", ")), u.HeaderFiles))
+	}
+	md.WriteString("\n")
+
+	if len(s.Conflicts) > 0 {
+		md.WriteString(tr(lang, "### Incompatible Combinations") + "\n\n")
+		for _, c := range s.Conflicts {
+			paths := make([]string, len(c.Paths))
+			for i, p := range c.Paths {
+				paths[i] = "`" + p + "`"
+			}
+			md.WriteString(fmt.Sprintf("- ⚠️ **%s**: %s (%s)\n", strings.Join(c.Licenses, " + "), c.Reason, strings.Join(paths, ", ")))
+		}
+		md.WriteString("\n")
+	}
+	return md.String()
+}
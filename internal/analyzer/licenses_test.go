@@ -0,0 +1,56 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchLicenseTextGNU(t *testing.T) {
+	for _, id := range []string{"GPL-2.0", "GPL-3.0", "LGPL-2.1", "LGPL-3.0", "AGPL-3.0"} {
+		data, err := licenseTexts.ReadFile("licenses/" + id + ".txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := MatchLicenseText(string(data), 0.8); got != id {
+			t.Errorf("MatchLicenseText(%s text) = %q, want %q", id, got, id)
+		}
+	}
+}
+
+func TestLicenseConflicts(t *testing.T) {
+	tests := []struct {
+		project  string
+		declared []string
+		want     []string
+	}{
+		// License texts alone leave the version open
+		{"", []string{"GPL-2.0", "Apache-2.0"}, nil},
+		{"", []string{"GPL-2.0", "GPL-3.0-only"}, nil},
+		{"", []string{"GPL-2.0-only", "GPL-3.0"}, nil},
+		{"", []string{"LGPL-2.1-only", "AGPL-3.0"}, nil},
+		{"", []string{"LGPL-2.1", "GPL-3.0-only"}, nil},
+		// SPDX headers state it
+		{"", []string{"GPL-2.0-only", "Apache-2.0"}, []string{"GPL-2.0-only + Apache-2.0"}},
+		{"", []string{"GPL-2.0-only", "GPL-3.0-or-later"}, []string{"GPL-2.0-only + GPL-3.0-or-later"}},
+		{"", []string{"LGPL-2.1-only", "AGPL-3.0-only"}, []string{"LGPL-2.1-only + AGPL-3.0-only"}},
+		{"", []string{"GPL-2.0-or-later", "Apache-2.0"}, nil},
+		// Strong copyleft code in a permissive project, whatever its version terms
+		{"MIT", []string{"MIT", "GPL-2.0"}, []string{"GPL-2.0 + MIT"}},
+		{"MIT OR Apache-2.0", []string{"GPL-2.0"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.project+" "+strings.Join(tt.declared, ","), func(t *testing.T) {
+			declared := make(map[string][]string)
+			for _, id := range tt.declared {
+				declared[id] = []string{"path/" + id}
+			}
+			var got []string
+			for _, c := range licenseConflicts(tt.project, declared) {
+				got = append(got, strings.Join(c.Licenses, " + "))
+			}
+			if strings.Join(got, "; ") != strings.Join(tt.want, "; ") {
+				t.Errorf("licenseConflicts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
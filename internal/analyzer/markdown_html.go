@@ -0,0 +1,141 @@
+package analyzer
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	mdHeadingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	mdBulletRe   = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	mdOrderedRe  = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	mdTableSepRe = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+	mdCodeRe     = regexp.MustCompile("`([^`]+)`")
+	mdBoldRe     = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	mdItalicRe   = regexp.MustCompile(`\*([^*\s][^*]*)\*`)
+	mdLinkRe     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+)
+
+// markdownToHTML converts the subset of markdown produced by the AI models
+// (headings, lists, fenced code, tables, emphasis and links) to HTML. It is
+// intentionally small: the HTML report must stay self-contained.
+func markdownToHTML(md string) string {
+	var out strings.Builder
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	var paragraph []string
+	listTag := ""
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + inlineMarkdown(strings.Join(paragraph, " ")) + "</p>\n")
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if listTag != "" {
+			out.WriteString("</" + listTag + ">\n")
+			listTag = ""
+		}
+	}
+	openList := func(tag string) {
+		if listTag != tag {
+			closeList()
+			out.WriteString("<" + tag + ">\n")
+			listTag = tag
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			flushParagraph()
+			closeList()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			out.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+		case trimmed == "":
+			flushParagraph()
+			closeList()
+		case mdHeadingRe.MatchString(trimmed):
+			flushParagraph()
+			closeList()
+			m := mdHeadingRe.FindStringSubmatch(trimmed)
+			level := len(m[1])
+			out.WriteString(fmt.Sprintf("<h%d>%s</h%d>\n", level, inlineMarkdown(m[2]), level))
+		case trimmed == "---" || trimmed == "***":
+			flushParagraph()
+			closeList()
+			out.WriteString("<hr>\n")
+		case strings.HasPrefix(trimmed, "|") && i+1 < len(lines) && mdTableSepRe.MatchString(lines[i+1]):
+			flushParagraph()
+			closeList()
+			out.WriteString("<table>\n<thead><tr>")
+			for _, cell := range tableCells(trimmed) {
+				out.WriteString("<th>" + inlineMarkdown(cell) + "</th>")
+			}
+			out.WriteString("</tr></thead>\n<tbody>\n")
+			for i += 2; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|"); i++ {
+				out.WriteString("<tr>")
+				for _, cell := range tableCells(strings.TrimSpace(lines[i])) {
+					out.WriteString("<td>" + inlineMarkdown(cell) + "</td>")
+				}
+				out.WriteString("</tr>\n")
+			}
+			i--
+			out.WriteString("</tbody>\n</table>\n")
+		case mdBulletRe.MatchString(line):
+			flushParagraph()
+			openList("ul")
+			out.WriteString("<li>" + inlineMarkdown(mdBulletRe.FindStringSubmatch(line)[1]) + "</li>\n")
+		case mdOrderedRe.MatchString(line):
+			flushParagraph()
+			openList("ol")
+			out.WriteString("<li>" + inlineMarkdown(mdOrderedRe.FindStringSubmatch(line)[1]) + "</li>\n")
+		case strings.HasPrefix(trimmed, ">"):
+			flushParagraph()
+			closeList()
+			out.WriteString("<blockquote>" + inlineMarkdown(strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))) + "</blockquote>\n")
+		default:
+			closeList()
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	flushParagraph()
+	closeList()
+	return out.String()
+}
+
+func tableCells(row string) []string {
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	cells := strings.Split(row, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+// inlineMarkdown escapes text and applies code spans, emphasis and links.
+// Only http(s) and fragment links are kept so the report cannot carry
+// script URLs.
+func inlineMarkdown(text string) string {
+	text = html.EscapeString(text)
+	text = mdCodeRe.ReplaceAllString(text, "<code>$1</code>")
+	text = mdBoldRe.ReplaceAllString(text, "<strong>$1$2</strong>")
+	text = mdItalicRe.ReplaceAllString(text, "<em>$1</em>")
+	text = mdLinkRe.ReplaceAllStringFunc(text, func(m string) string {
+		parts := mdLinkRe.FindStringSubmatch(m)
+		href := parts[2]
+		if !strings.HasPrefix(href, "http://") && !strings.HasPrefix(href, "https://") && !strings.HasPrefix(href, "#") {
+			return parts[1]
+		}
+		return fmt.Sprintf(`<a href="%s">%s</a>`, href, parts[1])
+	})
+	return text
+}
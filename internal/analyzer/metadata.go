@@ -0,0 +1,219 @@
+package analyzer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sniffSize is how much of a file is inspected to detect its MIME type and
+// whether it is text.
+const sniffSize = 8192
+
+// Metadata describes a file or a folder. For folders, Size is the total size
+// of the files below and the modification time and last commit are the most
+// recent of their descendants.
+type Metadata struct {
+	Size             int64      `json:"size"`
+	ModTime          time.Time  `json:"modTime"`
+	Mode             string     `json:"mode"`
+	MIMEType         string     `json:"mimeType,omitempty"`
+	Lines            int        `json:"lines,omitempty"`
+	Language         string     `json:"language,omitempty"`
+	SHA256           string     `json:"sha256,omitempty"`
+	LastCommitAuthor string     `json:"lastCommitAuthor,omitempty"`
+	LastCommitDate   *time.Time `json:"lastCommitDate,omitempty"`
+}
+
+// fileLanguages maps extensions to the language reported in the metadata.
+var fileLanguages = map[string]string{
+	".go": "Go", ".js": "JavaScript", ".jsx": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript",
+	".ts": "TypeScript", ".tsx": "TypeScript", ".mts": "TypeScript", ".cts": "TypeScript",
+	".py": "Python", ".rb": "Ruby", ".php": "PHP", ".java": "Java", ".kt": "Kotlin", ".kts": "Kotlin",
+	".scala": "Scala", ".swift": "Swift", ".m": "Objective-C", ".c": "C", ".h": "C",
+	".cpp": "C++", ".cc": "C++", ".cxx": "C++", ".hpp": "C++", ".hh": "C++", ".cs": "C#",
+	".rs": "Rust", ".dart": "Dart", ".lua": "Lua", ".pl": "Perl", ".r": "R", ".ex": "Elixir", ".exs": "Elixir",
+	".erl": "Erlang", ".hs": "Haskell", ".clj": "Clojure", ".fs": "F#", ".vb": "Visual Basic",
+	".sh": "Shell", ".bash": "Shell", ".zsh": "Shell", ".ps1": "PowerShell", ".bat": "Batch",
+	".sql": "SQL", ".html": "HTML", ".htm": "HTML", ".css": "CSS", ".scss": "SCSS", ".less": "Less",
+	".vue": "Vue", ".svelte": "Svelte", ".md": "Markdown", ".rst": "reStructuredText", ".tex": "TeX",
+	".json": "JSON", ".yaml": "YAML", ".yml": "YAML", ".toml": "TOML", ".xml": "XML", ".ini": "INI",
+	".cfg": "INI", ".conf": "Config", ".proto": "Protocol Buffers", ".graphql": "GraphQL", ".tf": "Terraform",
+	".csv": "CSV", ".txt": "Text",
+}
+
+// fileNameLanguages covers well-known files without a telling extension.
+var fileNameLanguages = map[string]string{
+	"Dockerfile": "Dockerfile", "Makefile": "Makefile", "CMakeLists.txt": "CMake",
+	"go.mod": "Go Module", "go.sum": "Go Module", "Gemfile": "Ruby", "Rakefile": "Ruby", "Jenkinsfile": "Groovy",
+}
+
+func detectLanguage(name string) string {
+	if lang, ok := fileNameLanguages[name]; ok {
+		return lang
+	}
+	return fileLanguages[strings.ToLower(filepath.Ext(name))]
+}
+
+// statMetadata returns the metadata available from the file info alone.
+func statMetadata(info os.FileInfo) *Metadata {
+	m := &Metadata{
+		ModTime: info.ModTime(),
+		Mode:    info.Mode().String(),
+	}
+	if !info.IsDir() {
+		m.Size = info.Size()
+		m.Language = detectLanguage(info.Name())
+	}
+	return m
+}
+
+// readFileMetadata fills in the metadata that needs the content: SHA-256,
+// MIME type and, for text files, the line count. The file is streamed, so
+// its size does not matter.
+func readFileMetadata(path string, m *Metadata) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	head := make([]byte, 0, sniffSize)
+	buf := make([]byte, 32*1024)
+	lines, size, lastByte := 0, int64(0), byte('\n')
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			chunk := buf[:n]
+			hash.Write(chunk)
+			if len(head) < sniffSize {
+				head = append(head, chunk[:min(n, sniffSize-len(head))]...)
+			}
+			lines += bytes.Count(chunk, []byte{'\n'})
+			lastByte = chunk[n-1]
+			size += int64(n)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	m.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	m.MIMEType = detectMIMEType(filepath.Base(path), head)
+	if size > 0 && !bytes.Contains(head, []byte{0}) {
+		if lastByte != '\n' {
+			lines++ // last line without a trailing newline
+		}
+		m.Lines = lines
+	}
+	return nil
+}
+
+// detectMIMEType prefers the type registered for the extension and falls
+// back to sniffing the first bytes.
+func detectMIMEType(name string, head []byte) string {
+	if t := mime.TypeByExtension(filepath.Ext(name)); t != "" {
+		return t
+	}
+	return http.DetectContentType(head)
+}
+
+// collectMetadata reads the content metadata of the files, a batch of files
+// at a time. The last commits are added by collectHistory.
+func (a *Analyzer) collectMetadata(rootPath string, fileNodes []*Node) {
+	if !a.config.Metadata.Enabled {
+		return
+	}
+
+	fmt.Printf("\n🔎 Collecting metadata for %d files...\n", len(fileNodes))
+	sem := make(chan struct{}, a.config.BatchSize)
+	var wg sync.WaitGroup
+	for _, n := range fileNodes {
+		if n.Metadata == nil {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(n *Node) {
+			defer func() { <-sem; wg.Done() }()
+			if err := readFileMetadata(n.Path, n.Metadata); err != nil {
+				fmt.Printf("⚠️  Could not read metadata of %s: %v\n", n.Path, err)
+			}
+		}(n)
+	}
+	wg.Wait()
+}
+
+// aggregateMetadata fills the folder metadata from their children.
+func aggregateMetadata(n *Node) {
+	if n == nil || n.Type != "directory" || n.Metadata == nil {
+		return
+	}
+	for _, ch := range n.Children {
+		aggregateMetadata(ch)
+		cm := ch.Metadata
+		if cm == nil {
+			continue
+		}
+		n.Metadata.Size += cm.Size
+		if cm.ModTime.After(n.Metadata.ModTime) {
+			n.Metadata.ModTime = cm.ModTime
+		}
+		if cm.LastCommitDate != nil && (n.Metadata.LastCommitDate == nil || cm.LastCommitDate.After(*n.Metadata.LastCommitDate)) {
+			n.Metadata.LastCommitDate = cm.LastCommitDate
+			n.Metadata.LastCommitAuthor = cm.LastCommitAuthor
+		}
+	}
+}
+
+// describeMetadata summarizes the metadata for the prompts, leaving out the
+// hash, which tells the model nothing.
+func describeMetadata(m *Metadata) string {
+	if m == nil {
+		return ""
+	}
+	parts := []string{"size: " + formatBytes(m.Size)}
+	if m.Lines > 0 {
+		parts = append(parts, fmt.Sprintf("lines: %d", m.Lines))
+	}
+	if m.Language != "" {
+		parts = append(parts, "language: "+m.Language)
+	}
+	if m.MIMEType != "" {
+		parts = append(parts, "MIME type: "+m.MIMEType)
+	}
+	parts = append(parts, "permissions: "+m.Mode)
+	if !m.ModTime.IsZero() {
+		parts = append(parts, "modified: "+m.ModTime.Format("2006-01-02"))
+	}
+	if m.LastCommitDate != nil {
+		parts = append(parts, fmt.Sprintf("last commit: %s by %s", m.LastCommitDate.Format("2006-01-02"), m.LastCommitAuthor))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -1,28 +1,34 @@
 package analyzer
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"archi/internal/config"
 )
 
-func GenerateEstimationMarkdown(estimation *CountEstimation) string {
+// GenerateEstimationMarkdown renders the estimation report, with headings in
+// the given output language.
+func GenerateEstimationMarkdown(estimation *CountEstimation, lang string) string {
 	var md strings.Builder
 
-	md.WriteString("# File and Folder Estimation\n\n")
-	md.WriteString(fmt.Sprintf("**Generated on:** %s\n\n", time.Now().Format("2006-01-02 15:04:05")))
+	md.WriteString(tr(lang, "# File and Folder Estimation") + "\n\n")
+	md.WriteString(fmt.Sprintf(tr(lang, "**Generated on:** %s")+"\n\n", time.Now().Format("2006-01-02 15:04:05")))
 
-	md.WriteString("## Summary\n\n")
-	md.WriteString(fmt.Sprintf("- **Total Files:** %d\n", estimation.TotalFiles))
-	md.WriteString(fmt.Sprintf("- **Total Folders:** %d\n", estimation.TotalFolders))
-	md.WriteString(fmt.Sprintf("- **Estimated Total Execution Time:** %s\n", formatDuration(estimation.TotalEstimatedTime)))
-	md.WriteString(fmt.Sprintf("  - File processing time (~4s each): %s\n", formatDuration(time.Duration(estimation.TotalFiles)*4*time.Second)))
-	md.WriteString(fmt.Sprintf("  - Folder processing time (~7s each): %s\n", formatDuration(time.Duration(estimation.TotalFolders)*7*time.Second)))
+	md.WriteString(tr(lang, "## Summary") + "\n\n")
+	md.WriteString(fmt.Sprintf(tr(lang, "- **Total Files:** %d")+"\n", estimation.TotalFiles))
+	md.WriteString(fmt.Sprintf(tr(lang, "- **Total Folders:** %d")+"\n", estimation.TotalFolders))
+	md.WriteString(fmt.Sprintf(tr(lang, "- **Estimated Total Execution Time:** %s")+"\n", formatDuration(estimation.TotalEstimatedTime)))
+	md.WriteString(fmt.Sprintf(tr(lang, "  - File processing time (~4s each): %s")+"\n", formatDuration(time.Duration(estimation.TotalFiles)*4*time.Second)))
+	md.WriteString(fmt.Sprintf(tr(lang, "  - Folder processing time (~7s each): %s")+"\n", formatDuration(time.Duration(estimation.TotalFolders)*7*time.Second)))
 	md.WriteString("\n")
 
-	md.WriteString("## File Types Analysis\n\n")
-	md.WriteString("| Extension | Count | Estimated Time |\n")
+	md.WriteString(tr(lang, "## File Types Analysis") + "\n\n")
+	md.WriteString(tr(lang, "| Extension | Count | Estimated Time |") + "\n")
 	md.WriteString("|-----------|-------|----------------|\n")
 	for _, stat := range estimation.FileTypeStats {
 		md.WriteString(fmt.Sprintf("| %s | %d | %s |\n",
@@ -30,8 +36,8 @@ func GenerateEstimationMarkdown(estimation *CountEstimation) string {
 	}
 	md.WriteString("\n")
 
-	md.WriteString("## Root Folders Analysis\n\n")
-	md.WriteString("| Folder Name | Files | Subfolders | Estimated Time |\n")
+	md.WriteString(tr(lang, "## Root Folders Analysis") + "\n\n")
+	md.WriteString(tr(lang, "| Folder Name | Files | Subfolders | Estimated Time |") + "\n")
 	md.WriteString("|-------------|-------|------------|----------------|\n")
 	for _, folder := range estimation.RootFolders {
 		md.WriteString(fmt.Sprintf("| %s | %d | %d | %s |\n",
@@ -39,46 +45,91 @@ func GenerateEstimationMarkdown(estimation *CountEstimation) string {
 	}
 	md.WriteString("\n")
 
-	md.WriteString("## Detailed Root Folder Breakdown\n\n")
+	md.WriteString(tr(lang, "## Detailed Root Folder Breakdown") + "\n\n")
 	for _, folder := range estimation.RootFolders {
 		md.WriteString(fmt.Sprintf("### %s\n\n", folder.Name))
-		md.WriteString(fmt.Sprintf("- **Path:** `%s`\n", folder.Path))
-		md.WriteString(fmt.Sprintf("- **Files:** %d (estimated %s for processing)\n",
+		md.WriteString(fmt.Sprintf(tr(lang, "- **Path:** `%s`")+"\n", folder.Path))
+		md.WriteString(fmt.Sprintf(tr(lang, "- **Files:** %d (estimated %s for processing)")+"\n",
 			folder.FileCount, formatDuration(time.Duration(folder.FileCount)*4*time.Second)))
-		md.WriteString(fmt.Sprintf("- **Subfolders:** %d (estimated %s for processing)\n",
+		md.WriteString(fmt.Sprintf(tr(lang, "- **Subfolders:** %d (estimated %s for processing)")+"\n",
 			folder.SubfolderCount, formatDuration(time.Duration(folder.SubfolderCount)*7*time.Second)))
-		md.WriteString(fmt.Sprintf("- **Total estimated time for this folder:** %s\n\n", formatDuration(folder.EstimatedTime)))
+		md.WriteString(fmt.Sprintf(tr(lang, "- **Total estimated time for this folder:** %s")+"\n\n", formatDuration(folder.EstimatedTime)))
 	}
 
 	md.WriteString("---\n\n")
-	md.WriteString("*This estimation calculates execution time based on 4 seconds per file and 7 seconds per folder for AI analysis.*\n")
+	md.WriteString(tr(lang, "*This estimation calculates execution time based on 4 seconds per file and 7 seconds per folder for AI analysis.*") + "\n")
 
 	return md.String()
 }
 
-func GenerateMarkdownOutput(rootNode *Node) string {
+// MarkdownOptions controls the layout of the markdown output.
+type MarkdownOptions struct {
+	// Style is "tree" (ASCII tree only) or "documentation" (per-folder sections with descriptions)
+	Style   string
+	Diagram config.TreeDiagramConfig
+	// Language of the headings and legend (outputLanguage)
+	Language string
+	// Hotspots is the number of git history hotspots listed (0 = none)
+	Hotspots int
+}
+
+func GenerateMarkdownOutput(output *AnalysisOutput, opts MarkdownOptions) string {
 	var markdown strings.Builder
+	rootNode := output.Tree
+	// Only flat analyses have an inventory, and they have no AI descriptions
+	flat := output.Inventory != nil
+
+	if opts.Style == "documentation" {
+		markdown.WriteString(generateDocumentation(rootNode, opts.Language))
+	} else {
+		markdown.WriteString(tr(opts.Language, "# Directory Tree Analysis") + "\n\n")
+		if flat {
+			markdown.WriteString(tr(opts.Language, "This document shows the analyzed directory structure with statistics computed locally, without AI descriptions.") + "\n\n")
+		} else {
+			markdown.WriteString(tr(opts.Language, "This document shows the analyzed directory structure with AI-generated descriptions.") + "\n\n")
+		}
+		markdown.WriteString(tr(opts.Language, "## Tree Structure") + "\n\n")
 
-	markdown.WriteString("# Directory Tree Analysis\n\n")
-	markdown.WriteString("This document shows the analyzed directory structure with AI-generated descriptions.\n\n")
-	markdown.WriteString("## Tree Structure\n\n")
+		markdown.WriteString("```\n")
+		markdown.WriteString(generateMarkdownTree(rootNode, 0, true, []bool{}))
+		markdown.WriteString("```\n\n")
+	}
 
-	markdown.WriteString("```\n")
-	markdown.WriteString(generateMarkdownTree(rootNode, 0, true, []bool{}))
-	markdown.WriteString("```\n\n")
+	markdown.WriteString(generateInventorySection(output.Inventory, opts.Language))
+	markdown.WriteString(generateLicensesSection(output.Licenses, opts.Language))
+	markdown.WriteString(generateChangesSection(output, opts.Language))
+	markdown.WriteString(generateHotspotsSection(rootNode, opts.Hotspots, opts.Language))
+	markdown.WriteString(generateDuplicatesSection(output.Duplicates, opts.Language))
+	markdown.WriteString(generateNearDuplicatesSection(output.NearDuplicates, opts.Language))
+	markdown.WriteString(generateSecuritySection(rootNode, opts.Language))
+
+	if opts.Diagram.Style != "none" {
+		markdown.WriteString(tr(opts.Language, "## Tree Diagram") + "\n\n")
+		markdown.WriteString("```mermaid\n")
+		markdown.WriteString(GenerateTreeMermaid(rootNode, opts.Diagram))
+		markdown.WriteString("```\n\n")
+	}
 
-	markdown.WriteString("## Legend\n\n")
-	markdown.WriteString("- 📁 Directory\n")
-	markdown.WriteString("- 📄 Text/Generic file\n")
-	markdown.WriteString("- 🖼️ Image file\n")
-	markdown.WriteString("- 📋 PDF document\n")
-	markdown.WriteString("- 📝 Word document\n")
-	markdown.WriteString("- 📊 Excel spreadsheet\n")
-	markdown.WriteString("- 🐹 Go source file\n")
-	markdown.WriteString("- 📖 Markdown file\n")
-	markdown.WriteString("- 📊 JSON file\n\n")
+	markdown.WriteString(tr(opts.Language, "## Legend") + "\n\n")
+	legend := []struct{ icon, label string }{
+		{"📁", "Directory"},
+		{"📄", "Text/Generic file"},
+		{"🖼️", "Image file"},
+		{"📋", "PDF document"},
+		{"📝", "Word document"},
+		{"📊", "Excel spreadsheet"},
+		{"🐹", "Go source file"},
+		{"📖", "Markdown file"},
+		{"📊", "JSON file"},
+	}
+	for _, entry := range legend {
+		markdown.WriteString(fmt.Sprintf("- %s %s\n", entry.icon, tr(opts.Language, entry.label)))
+	}
+	markdown.WriteString("\n")
 
-	markdown.WriteString("*Descriptions are AI-generated based on file content analysis.*\n")
+	if !flat {
+		markdown.WriteString(tr(opts.Language, "*Descriptions are AI-generated based on file content analysis.*") + "\n")
+	}
 
 	return markdown.String()
 }
@@ -103,30 +154,10 @@ func generateMarkdownTree(node *Node, depth int, isLast bool, parentPrefixes []b
 		}
 	}
 
-	icon := "📄"
-	if node.Type == "directory" {
-		icon = "📁"
-	} else {
-		ext := strings.ToLower(filepath.Ext(node.Name))
-		switch ext {
-		case ".png", ".jpg", ".jpeg", ".gif", ".bmp", ".webp":
-			icon = "🖼️"
-		case ".pdf":
-			icon = "📋"
-		case ".docx", ".doc":
-			icon = "📝"
-		case ".xlsx", ".xls":
-			icon = "📊"
-		case ".go":
-			icon = "🐹"
-		case ".md":
-			icon = "📖"
-		case ".json":
-			icon = "📊"
-		}
+	markdown.WriteString(fmt.Sprintf("%s%s %s", prefix, nodeIcon(node), node.Name))
+	if node.ChangeStatus != "" && node.ChangeStatus != StatusUnchanged {
+		markdown.WriteString(" [" + node.ChangeStatus + "]")
 	}
-
-	markdown.WriteString(fmt.Sprintf("%s%s %s", prefix, icon, node.Name))
 	markdown.WriteString("\n")
 
 	if len(node.Children) > 0 {
@@ -143,6 +174,29 @@ func generateMarkdownTree(node *Node, depth int, isLast bool, parentPrefixes []b
 	return markdown.String()
 }
 
+func nodeIcon(node *Node) string {
+	if node.Type == "directory" {
+		return "📁"
+	}
+	switch strings.ToLower(filepath.Ext(node.Name)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".bmp", ".webp":
+		return "🖼️"
+	case ".pdf":
+		return "📋"
+	case ".docx", ".doc":
+		return "📝"
+	case ".xlsx", ".xls":
+		return "📊"
+	case ".go":
+		return "🐹"
+	case ".md":
+		return "📖"
+	case ".json":
+		return "📊"
+	}
+	return "📄"
+}
+
 func formatDuration(d time.Duration) string {
 	if d < time.Minute {
 		return fmt.Sprintf("%.1fs", d.Seconds())
@@ -156,3 +210,29 @@ func formatDuration(d time.Duration) string {
 		return fmt.Sprintf("%dh %dm", hours, minutes)
 	}
 }
+
+// LoadAnalysisOutput reads a JSON output file. Files written before the
+// AnalysisOutput envelope existed contain the bare Node tree and are wrapped.
+func LoadAnalysisOutput(path string) (*AnalysisOutput, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var output AnalysisOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("error decoding %s: %w", path, err)
+	}
+	if output.Tree != nil {
+		return &output, nil
+	}
+
+	var legacy Node
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("error decoding %s: %w", path, err)
+	}
+	if legacy.Path == "" && legacy.Name == "" {
+		return nil, fmt.Errorf("%s does not contain an analysis tree", path)
+	}
+	return &AnalysisOutput{Tree: &legacy}, nil
+}
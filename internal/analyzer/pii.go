@@ -0,0 +1,290 @@
+package analyzer
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"archi/internal/config"
+)
+
+var (
+	emailRe = regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)
+	ibanRe  = regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]){11,30}\b`)
+	// International numbers, and national numbers written in groups such as
+	// 06 12 34 56 78 or (555) 123-4567
+	phoneRe = regexp.MustCompile(`(?:\+|\b00)\d{1,3}(?:[ .-]?\(?\d{1,4}\)?){2,6}\b|(?:\(\d{2,4}\)[ .-]?|\b\d{2,4}[ .-])\d{2,4}(?:[ .-]\d{2,4}){1,4}\b`)
+	// Dates and IPv4 addresses look like grouped numbers
+	notPhoneRe = regexp.MustCompile(`^(?:\d{4}[./-]\d{1,2}[./-]\d{1,2}|\d{1,2}[./-]\d{1,2}[./-]\d{4}|\d{1,3}(?:\.\d{1,3}){3})$`)
+
+	ssnRe  = regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)
+	nirRe  = regexp.MustCompile(`\b[12] ?\d{2} ?\d{2} ?(?:\d{2}|2[AB]) ?\d{3} ?\d{3} ?\d{2}\b`)
+	ninoRe = regexp.MustCompile(`\b[A-CEGHJ-PR-TW-Z][A-CEGHJ-NPR-TW-Z] ?\d{2} ?\d{2} ?\d{2} ?[A-D]\b`)
+)
+
+// PIIFilter finds and redacts personal data in the text extracted from files.
+type PIIFilter struct {
+	categories map[string]bool
+	extensions map[string]bool
+	names      *regexp.Regexp
+}
+
+// NewPIIFilter returns the filter configured by cfg, or nil when PII
+// redaction is disabled. A names file that cannot be read is an error, so
+// that the names it lists are never sent unredacted.
+func NewPIIFilter(cfg config.PIIConfig) (*PIIFilter, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	f := &PIIFilter{categories: make(map[string]bool), extensions: make(map[string]bool)}
+	for _, c := range cfg.Categories {
+		f.categories[c] = true
+	}
+	for _, ext := range cfg.Extensions {
+		f.extensions[strings.ToLower(ext)] = true
+	}
+
+	names := append([]string(nil), cfg.Names...)
+	if cfg.NamesFile != "" {
+		data, err := os.ReadFile(cfg.NamesFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading PII names file: %v", err)
+		}
+		names = append(names, strings.Split(string(data), "\n")...)
+	}
+	var quoted []string
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" && !strings.HasPrefix(name, "#") {
+			quoted = append(quoted, regexp.QuoteMeta(name))
+		}
+	}
+	if f.categories["name"] && len(quoted) > 0 {
+		// Longest first, so that "Jean Dupont" wins over "Jean"
+		sort.Slice(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+		f.names = regexp.MustCompile(`(?i)(?:` + strings.Join(quoted, "|") + `)`)
+	}
+	return f, nil
+}
+
+// findNames returns the spans of the configured names that are whole words.
+// The word boundaries are checked on the neighbouring runes, as \b only knows
+// ASCII letters and would cut "Émilie" or "Zoë" short.
+func (f *PIIFilter) findNames(content string) [][]int {
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' }
+	var spans [][]int
+	for pos := 0; pos < len(content); {
+		loc := f.names.FindStringIndex(content[pos:])
+		if loc == nil {
+			break
+		}
+		start, end := pos+loc[0], pos+loc[1]
+		if before, _ := utf8.DecodeLastRuneInString(content[:start]); start == 0 || !isWord(before) {
+			// The longest name may run into a word where a shorter one ends
+			for end > start {
+				if after, _ := utf8.DecodeRuneInString(content[end:]); end == len(content) || !isWord(after) {
+					break
+				}
+				loc = f.names.FindStringIndex(content[start : end-1])
+				if loc == nil || loc[0] != 0 {
+					end = start
+					break
+				}
+				end = start + loc[1]
+			}
+			if end > start {
+				spans = append(spans, []int{start, end})
+				pos = end
+				continue
+			}
+		}
+		_, size := utf8.DecodeRuneInString(content[start:])
+		pos = start + size
+	}
+	return spans
+}
+
+// Applies reports whether the filter redacts the file at path.
+func (f *PIIFilter) Applies(path string) bool {
+	return f != nil && (len(f.extensions) == 0 || f.extensions[strings.ToLower(filepath.Ext(path))])
+}
+
+// Redact replaces the personal data found in content with placeholders such
+// as [EMAIL_1] or [NAME_2]; the same value always gets the same placeholder
+// within a file. It returns the redacted content with the number of values
+// redacted per category.
+func (f *PIIFilter) Redact(content string) (string, map[string]int) {
+	if f == nil {
+		return content, nil
+	}
+	var spans []redaction
+	add := func(category string, re *regexp.Regexp, valid func(string) bool) {
+		if !f.categories[category] || re == nil {
+			return
+		}
+		for _, loc := range re.FindAllStringIndex(content, -1) {
+			if valid == nil || valid(content[loc[0]:loc[1]]) {
+				spans = append(spans, redaction{loc[0], loc[1], category})
+			}
+		}
+	}
+	// Stronger formats first: of two identical spans, the first added wins
+	add("email", emailRe, nil)
+	add("iban", ibanRe, validIBAN)
+	add("national-id", ssnRe, validSSN)
+	add("national-id", nirRe, validNIR)
+	add("national-id", ninoRe, nil)
+	add("phone", phoneRe, validPhone)
+	if f.categories["name"] && f.names != nil {
+		for _, loc := range f.findNames(content) {
+			spans = append(spans, redaction{loc[0], loc[1], "name"})
+		}
+	}
+
+	counts := make(map[string]int)
+	tokens := make(map[string]string)
+	numbers := make(map[string]int)
+	redacted := redactSpans(content, spans, func(r redaction, _ int) string {
+		counts[r.label]++
+		key := r.label + "\x00" + normalizePII(r.label, content[r.start:r.end])
+		token, ok := tokens[key]
+		if !ok {
+			numbers[r.label]++
+			token = fmt.Sprintf("[%s_%d]", strings.ToUpper(strings.ReplaceAll(r.label, "-", "_")), numbers[r.label])
+			tokens[key] = token
+		}
+		return token
+	})
+	if len(counts) == 0 {
+		return content, nil
+	}
+	return redacted, counts
+}
+
+// normalizePII returns the form of a value compared to give the same value
+// the same placeholder.
+func normalizePII(category, value string) string {
+	switch category {
+	case "phone", "iban", "national-id":
+		return strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToUpper(r)
+			}
+			return -1
+		}, value)
+	}
+	return strings.ToLower(value)
+}
+
+// validPhone keeps the candidates with 8 to 15 digits that are not dates or
+// IP addresses.
+func validPhone(s string) bool {
+	if notPhoneRe.MatchString(s) {
+		return false
+	}
+	digits := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	return digits >= 8 && digits <= 15
+}
+
+// validIBAN checks the ISO 13616 mod-97 check digits.
+func validIBAN(s string) bool {
+	s = strings.ReplaceAll(s, " ", "")
+	if len(s) < 15 || len(s) > 34 {
+		return false
+	}
+	var digits strings.Builder
+	for _, r := range s[4:] + s[:4] {
+		if r >= 'A' && r <= 'Z' {
+			digits.WriteString(fmt.Sprint(r - 'A' + 10))
+		} else {
+			digits.WriteRune(r)
+		}
+	}
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	return ok && new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+// validSSN rejects the US social security numbers never issued.
+func validSSN(s string) bool {
+	area, group, serial := s[0:3], s[4:6], s[7:11]
+	return area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+}
+
+// validNIR checks the key of a French social security number (the last two
+// digits are 97 minus the first thirteen modulo 97).
+func validNIR(s string) bool {
+	s = strings.ReplaceAll(s, " ", "")
+	s = strings.NewReplacer("2A", "19", "2B", "18").Replace(s[:13]) + s[13:]
+	n, ok := new(big.Int).SetString(s[:13], 10)
+	if !ok {
+		return false
+	}
+	key := 97 - new(big.Int).Mod(n, big.NewInt(97)).Int64()
+	return fmt.Sprintf("%02d", key) == s[13:]
+}
+
+// redactPII redacts the personal data of a file's extracted text and records
+// the categories found on its node.
+func (a *Analyzer) redactPII(n *Node, content string) string {
+	if !a.pii.Applies(n.Path) {
+		return content
+	}
+	redacted, counts := a.pii.Redact(content)
+	n.PII = counts
+	if len(counts) > 0 {
+		fmt.Printf("\n🪪 Redacted personal data (%s) in %s\n", formatPIICounts(counts), n.Path)
+	}
+	return redacted
+}
+
+// formatPIICounts lists the categories in their configuration order, e.g.
+// "email (2), phone (1)".
+func formatPIICounts(counts map[string]int) string {
+	var parts []string
+	for _, c := range config.PIICategories {
+		if counts[c] > 0 {
+			parts = append(parts, fmt.Sprintf("%s (%d)", c, counts[c]))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// personalDataSubsection lists the categories of personal data redacted per
+// file.
+func personalDataSubsection(root *Node, lang string) string {
+	var rows []string
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if len(n.PII) > 0 {
+			rows = append(rows, fmt.Sprintf("| `%s` | %s |\n", escapeTableCell(displayPath(root, n)), formatPIICounts(n.PII)))
+		}
+		for _, ch := range n.Children {
+			walk(ch)
+		}
+	}
+	walk(root)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	var md strings.Builder
+	md.WriteString(tr(lang, "### Personal Data") + "\n\n")
+	md.WriteString(fmt.Sprintf(tr(lang, "Personal data was replaced by placeholders in %d files before the analysis.")+"\n\n", len(rows)))
+	md.WriteString(tr(lang, "| File | Categories |") + "\n")
+	md.WriteString("|---|---|\n")
+	for _, r := range rows {
+		md.WriteString(r)
+	}
+	md.WriteString("\n")
+	return md.String()
+}
@@ -0,0 +1,75 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"archi/internal/config"
+)
+
+func TestRedactNames(t *testing.T) {
+	f, err := NewPIIFilter(config.PIIConfig{
+		Enabled:    true,
+		Categories: []string{"name"},
+		Names:      []string{"Émilie Durand", "José", "Zoë", "Jean", "Jean Dupont", "Ana"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		content string
+		want    string
+		count   int
+	}{
+		{"Émilie Durand a signé.", "[NAME_1] a signé.", 1},
+		{"Signé: émilie durand", "Signé: [NAME_1]", 1},
+		{"José,Zoë et ZOË", "[NAME_1],[NAME_2] et [NAME_2]", 3},
+		{"(José)", "([NAME_1])", 1},
+		{"Jean Dupont et Jean", "[NAME_1] et [NAME_2]", 2},
+		// Names inside longer words are left alone
+		{"Josépha, Zoëlle, Jeanne", "Josépha, Zoëlle, Jeanne", 0},
+		{"Jean_Dupont, Jean2", "Jean_Dupont, Jean2", 0},
+		{"Banana Ana", "Banana [NAME_1]", 1},
+		// A longer name that is not a whole word does not hide a shorter one
+		{"Jean Dupontel", "[NAME_1] Dupontel", 1},
+		{"àJosé José", "àJosé [NAME_1]", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			got, counts := f.Redact(tt.content)
+			if got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+			if counts["name"] != tt.count {
+				t.Errorf("Redact(%q) counted %d names, want %d", tt.content, counts["name"], tt.count)
+			}
+		})
+	}
+}
+
+func TestNewPIIFilterNamesFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "names.txt")
+	if err := os.WriteFile(path, []byte("# staff\nZoë\n\nÉmilie Durand\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.PIIConfig{Enabled: true, Categories: []string{"name"}, NamesFile: path}
+	f, err := NewPIIFilter(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, counts := f.Redact("Zoë écrit à Émilie Durand.")
+	if want := "[NAME_1] écrit à [NAME_2]."; got != want {
+		t.Errorf("Redact = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(counts, map[string]int{"name": 2}) {
+		t.Errorf("counts = %v", counts)
+	}
+
+	cfg.NamesFile = filepath.Join(dir, "missing.txt")
+	if _, err := NewPIIFilter(cfg); err == nil {
+		t.Errorf("NewPIIFilter with a missing names file succeeded")
+	}
+}
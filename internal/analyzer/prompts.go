@@ -0,0 +1,161 @@
+package analyzer
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"archi/internal/config"
+)
+
+//go:embed prompts/*.tmpl
+var builtinPrompts embed.FS
+
+// Prompt template names. Each name matches a built-in prompts/<name>.tmpl
+// file and the key used by the prompts config and the prompt directory.
+const (
+	PromptFile         = "file"
+	PromptFolder       = "folder"
+	PromptImage        = "image"
+	PromptArchitecture = "architecture"
+	PromptCombine      = "combine"
+	PromptRepair       = "repair"
+	PromptLayout       = "layout"
+	PromptEvolution    = "evolution"
+	PromptSecurity     = "security"
+)
+
+// PromptNames lists the customizable prompts.
+var PromptNames = []string{PromptFile, PromptFolder, PromptImage, PromptArchitecture, PromptCombine, PromptRepair, PromptLayout, PromptEvolution, PromptSecurity}
+
+// PromptData holds the variables available to the prompt templates. Each
+// template documents the subset it uses.
+type PromptData struct {
+	FileName        string
+	Path            string
+	Content         string
+	Metadata        string
+	Children        []PromptChild
+	OmittedChildren int
+	ProjectContext  string
+	Language        string
+	Schema          string
+	Problems        []string
+	Report          string
+	Paths           string
+	Hotspots        string
+	NearDuplicates  string
+	Sampled         bool
+}
+
+// PromptChild is a folder entry listed in the folder prompt.
+type PromptChild struct {
+	Name        string
+	IsDir       bool
+	Items       int
+	Description string
+}
+
+// Prompts holds the parsed prompt templates.
+type Prompts struct {
+	templates map[string]*template.Template
+	sources   map[string]string
+}
+
+// BuiltinPrompt returns the source of a built-in prompt template.
+func BuiltinPrompt(name string) (string, error) {
+	data, err := builtinPrompts.ReadFile("prompts/" + name + ".tmpl")
+	if err != nil {
+		return "", fmt.Errorf("unknown prompt %q", name)
+	}
+	return string(data), nil
+}
+
+// LoadPrompts parses the prompt templates. For each prompt, an inline
+// template from the prompts config takes precedence over <promptDir>/<name>.tmpl,
+// which takes precedence over the built-in default.
+func LoadPrompts(cfg *config.Config) (*Prompts, error) {
+	for name := range cfg.Prompts {
+		if !isPromptName(name) {
+			return nil, fmt.Errorf("prompts.%s: unknown prompt (expected one of %s)", name, strings.Join(PromptNames, ", "))
+		}
+	}
+
+	p := &Prompts{
+		templates: make(map[string]*template.Template),
+		sources:   make(map[string]string),
+	}
+	for _, name := range PromptNames {
+		text, err := BuiltinPrompt(name)
+		if err != nil {
+			return nil, err
+		}
+		source := "built-in"
+
+		if cfg.PromptDir != "" {
+			file := filepath.Join(cfg.PromptDir, name+".tmpl")
+			data, err := os.ReadFile(file)
+			if err == nil {
+				text, source = string(data), file
+			} else if !os.IsNotExist(err) {
+				return nil, fmt.Errorf("error reading prompt %s: %w", file, err)
+			}
+		}
+		// Viper lower-cases map keys, which matches the prompt names
+		if inline, ok := cfg.Prompts[name]; ok && strings.TrimSpace(inline) != "" {
+			text, source = inline, "prompts."+name
+		}
+
+		tmpl, err := template.New(name).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing prompt %s (%s): %w", name, source, err)
+		}
+		p.templates[name] = tmpl
+		p.sources[name] = source
+	}
+	return p, nil
+}
+
+func isPromptName(name string) bool {
+	for _, n := range PromptNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Source reports where a prompt was loaded from: "built-in", a file path or
+// the prompts config key.
+func (p *Prompts) Source(name string) string {
+	return p.sources[name]
+}
+
+// Customized lists the prompts that do not use the built-in template.
+func (p *Prompts) Customized() []string {
+	var names []string
+	for name, source := range p.sources {
+		if source != "built-in" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render executes a prompt template.
+func (p *Prompts) Render(name string, data PromptData) (string, error) {
+	tmpl, ok := p.templates[name]
+	if !ok {
+		return "", fmt.Errorf("unknown prompt %q", name)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("error rendering prompt %s: %w", name, err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,94 @@
+package analyzer
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ReadmeMarker identifies READMEs written by archi. Folders whose README lacks
+// it are left untouched by "archi docs write".
+const ReadmeMarker = readmeMarkerPrefix + " — this file is regenerated by `archi docs write`; remove this line to keep manual edits -->"
+
+const readmeMarkerPrefix = "<!-- archi:generated"
+
+// IsGeneratedReadme reports whether a README carries the archi marker.
+func IsGeneratedReadme(content string) bool {
+	return strings.Contains(content, readmeMarkerPrefix)
+}
+
+// GenerateFolderReadme renders the README for a folder: its description,
+// links to its subfolders and an index of its files with their descriptions.
+func GenerateFolderReadme(folder *Node) string {
+	var md strings.Builder
+
+	md.WriteString(ReadmeMarker + "\n\n")
+	md.WriteString(fmt.Sprintf("# %s\n\n", folder.Name))
+	if desc := strings.TrimSpace(folder.Description); desc != "" {
+		md.WriteString(desc + "\n\n")
+	} else {
+		md.WriteString("*No description available.*\n\n")
+	}
+
+	var files, subfolders []*Node
+	for _, child := range folder.Children {
+		if child.Type == "directory" {
+			subfolders = append(subfolders, child)
+		} else {
+			files = append(files, child)
+		}
+	}
+
+	if len(subfolders) > 0 {
+		md.WriteString("## Subfolders\n\n")
+		for _, sub := range subfolders {
+			md.WriteString(fmt.Sprintf("- 📁 [%s](%s/)", sub.Name, readmeLink(sub.Name)))
+			if sentence := firstSentence(sub.Description); sentence != "" {
+				md.WriteString(" — " + sentence)
+			}
+			md.WriteString("\n")
+		}
+		md.WriteString("\n")
+	}
+
+	if len(files) > 0 {
+		md.WriteString("## Files\n\n")
+		md.WriteString("| File | Description |\n")
+		md.WriteString("|------|-------------|\n")
+		for _, f := range files {
+			md.WriteString(fmt.Sprintf("| %s [%s](%s) | %s |\n",
+				nodeIcon(f), escapeTableCell(f.Name), readmeLink(f.Name), escapeTableCell(f.Description)))
+		}
+		md.WriteString("\n")
+	}
+
+	md.WriteString("---\n\n")
+	md.WriteString("*Generated by archi from the directory analysis. Descriptions are AI-generated.*\n")
+	return md.String()
+}
+
+func readmeLink(name string) string {
+	return (&url.URL{Path: path.Clean(name)}).EscapedPath()
+}
+
+// FindReadme returns the path of an existing README in dir (any case, any
+// extension), or "" when there is none.
+func FindReadme(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := strings.ToLower(e.Name())
+		if name == "readme" || strings.HasPrefix(name, "readme.") {
+			return filepath.Join(dir, e.Name())
+		}
+	}
+	return ""
+}
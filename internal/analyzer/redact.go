@@ -0,0 +1,44 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+)
+
+// redaction is a span of content to replace.
+type redaction struct {
+	start, end int
+	label      string
+}
+
+// redactSpans replaces the spans of content with the text returned by
+// replace, which also receives the line of the span. The replacement is
+// followed by the newlines of the span, so that the lines after it keep their
+// numbers. Of overlapping spans, the earliest, then the longest, then the
+// first added is kept.
+func redactSpans(content string, spans []redaction, replace func(r redaction, line int) string) string {
+	if len(spans) == 0 {
+		return content
+	}
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+	var sb strings.Builder
+	pos, line, counted := 0, 1, 0
+	for _, r := range spans {
+		if r.start < pos {
+			continue
+		}
+		line += strings.Count(content[counted:r.start], "\n")
+		counted = r.start
+		sb.WriteString(content[pos:r.start])
+		sb.WriteString(replace(r, line))
+		sb.WriteString(strings.Repeat("\n", strings.Count(content[r.start:r.end], "\n")))
+		pos = r.end
+	}
+	sb.WriteString(content[pos:])
+	return sb.String()
+}
@@ -0,0 +1,22 @@
+package analyzer
+
+import "testing"
+
+func TestRedactSpansKeepsLines(t *testing.T) {
+	content := "a\n-----BEGIN KEY-----\nxyz\n-----END KEY-----\nb SECRET c\nd"
+	key := len("a\n")
+	keyEnd := key + len("-----BEGIN KEY-----\nxyz\n-----END KEY-----")
+	secret := keyEnd + len("\nb ")
+	spans := []redaction{{secret, secret + len("SECRET"), "token"}, {key, keyEnd, "private-key"}}
+	var lines []int
+	got := redactSpans(content, spans, func(r redaction, line int) string {
+		lines = append(lines, line)
+		return "[" + r.label + "]"
+	})
+	if want := "a\n[private-key]\n\n\nb [token] c\nd"; got != want {
+		t.Errorf("redactSpans = %q, want %q", got, want)
+	}
+	if len(lines) != 2 || lines[0] != 2 || lines[1] != 5 {
+		t.Errorf("lines = %v, want [2 5]", lines)
+	}
+}
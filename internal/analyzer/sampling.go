@@ -0,0 +1,324 @@
+package analyzer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// minSampleSectionTokens is the smallest section worth showing; windows
+	// are dropped until every section gets at least this much
+	minSampleSectionTokens = 64
+	// sampleHeaderTokens is kept for the stats and the omission markers
+	sampleHeaderTokens = 120
+	// maxSampledLineBytes caps what is kept of a single line
+	maxSampledLineBytes = 4096
+)
+
+var logLevelRe = regexp.MustCompile(`\b(TRACE|DEBUG|INFO|WARN|WARNING|ERROR|FATAL|CRITICAL)\b`)
+
+// sampleSection is a run of consecutive lines kept from a sampled file. A
+// rolling section (the tail) drops its oldest lines to make room for new ones,
+// so that it ends with the last line of the file.
+type sampleSection struct {
+	start   int64
+	tokens  int
+	rolling bool
+	full    bool
+	lines   []string
+	costs   []int
+	first   int
+	last    int
+}
+
+// add keeps a line when the section has room for it. A line too long for an
+// empty section is shortened; otherwise a line that does not fit fills the
+// section and is left to the next one.
+func (s *sampleSection) add(line string, lineNo int) bool {
+	if s.full {
+		return false
+	}
+	cost := EstimateTokens(line) + 1
+	for s.rolling && cost > s.tokens && len(s.lines) > 0 {
+		s.tokens += s.costs[0]
+		s.lines, s.costs = s.lines[1:], s.costs[1:]
+		s.first++
+	}
+	if cost > s.tokens {
+		if len(s.lines) > 0 {
+			s.full = true
+			return false
+		}
+		line = truncateInline(line, s.tokens-1)
+		cost = s.tokens
+		s.full = !s.rolling
+	}
+	if len(s.lines) == 0 {
+		s.first = lineNo
+	}
+	s.lines = append(s.lines, line)
+	s.costs = append(s.costs, cost)
+	s.last = lineNo
+	s.tokens -= cost
+	return true
+}
+
+// sampleStats describes the whole file, including the lines left out.
+type sampleStats struct {
+	lines   int
+	blank   int
+	longest int64
+	columns int
+	comma   rune
+	levels  map[string]int
+}
+
+// SampleFile reads a file too large to be sent whole in a single pass and
+// keeps its head, windows evenly spaced through the middle and its tail,
+// within maxTokens estimated tokens. The sample starts with the line count
+// and structure stats of the file, and the omitted lines are marked. The
+// second result gives the line number in the file of each line of the
+// sample, 0 for the stats and the markers.
+func SampleFile(path string, maxTokens, windows int) (string, []int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", nil, err
+	}
+	size := info.Size()
+
+	budget := maxTokens - sampleHeaderTokens
+	if budget < minSampleSectionTokens {
+		return "", nil, fmt.Errorf("file too large")
+	}
+	for windows > 0 && budget/(windows+2) < minSampleSectionTokens {
+		windows--
+	}
+	count := windows + 2
+	if budget/count < minSampleSectionTokens {
+		count = 1
+	}
+	per := budget / count
+	// Sections are placed by byte offset, assuming about 4 bytes per token
+	span := int64(per) * 4
+	sections := make([]*sampleSection, count)
+	sections[0] = &sampleSection{tokens: per}
+	for k := 1; k < count-1; k++ {
+		start := size*int64(k)/int64(count-1) - span/2
+		sections[k] = &sampleSection{start: max(start, 0), tokens: per}
+	}
+	if count > 1 {
+		sections[count-1] = &sampleSection{start: max(size-span, 0), tokens: per, rolling: true}
+	}
+
+	stats := sampleStats{levels: make(map[string]int)}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		stats.comma = ','
+	case ".tsv":
+		stats.comma = '\t'
+	}
+	isLog := strings.EqualFold(filepath.Ext(path), ".log")
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	var offset int64
+	current := 0
+	for {
+		line, length, width, err := readSampledLine(r)
+		if length == 0 && err != nil {
+			if err == io.EOF {
+				break
+			}
+			return "", nil, err
+		}
+		stats.lines++
+		if strings.TrimSpace(line) == "" {
+			stats.blank++
+		}
+		stats.longest = max(stats.longest, width)
+		if stats.lines == 1 && stats.comma != 0 {
+			stats.columns = countColumns(line, stats.comma)
+		}
+		if isLog {
+			if level := logLevelRe.FindString(line); level != "" {
+				stats.levels[level]++
+			}
+		}
+		if int64(len(line)) < width {
+			line += " …"
+		}
+		for current < len(sections) && offset >= sections[current].start {
+			if sections[current].add(line, stats.lines) {
+				break
+			}
+			current++
+		}
+		offset += length
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	var b strings.Builder
+	var lineMap []int
+	write := func(s string, lineNo int) {
+		b.WriteString(s)
+		b.WriteString("\n")
+		lineMap = append(lineMap, lineNo)
+	}
+	shown := 0
+	for _, s := range sections {
+		if len(s.lines) > 0 {
+			shown++
+		}
+	}
+	write(describeSample(size, stats, shown), 0)
+	if stats.columns > 0 {
+		write(fmt.Sprintf("[columns: %d]", stats.columns), 0)
+	}
+	if len(stats.levels) > 0 {
+		write(fmt.Sprintf("[log levels: %s]", formatLevels(stats.levels)), 0)
+	}
+	write("", 0)
+	previous := 0
+	for _, s := range sections {
+		if len(s.lines) == 0 {
+			continue
+		}
+		if s.first > previous+1 {
+			write(sampleMarker(previous+1, s.first-1), 0)
+		}
+		for i, line := range s.lines {
+			write(line, s.first+i)
+		}
+		previous = s.last
+	}
+	if previous < stats.lines {
+		write(sampleMarker(previous+1, stats.lines), 0)
+	}
+	return strings.TrimSuffix(b.String(), "\n"), lineMap, nil
+}
+
+// readSampledLine reads up to the next newline, keeping at most
+// maxSampledLineBytes of it. It returns the bytes consumed, newline included,
+// and the width of the line without it.
+func readSampledLine(r *bufio.Reader) (string, int64, int64, error) {
+	var kept []byte
+	var consumed int64
+	for {
+		chunk, err := r.ReadSlice('\n')
+		consumed += int64(len(chunk))
+		if room := maxSampledLineBytes - len(kept); room > 0 {
+			kept = append(kept, chunk[:min(room, len(chunk))]...)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		width := consumed
+		if strings.HasSuffix(string(chunk), "\r\n") {
+			width -= 2
+		} else if strings.HasSuffix(string(chunk), "\n") {
+			width--
+		}
+		line := strings.TrimRight(string(kept), "\r\n")
+		if int64(len(line)) < width {
+			line = strings.ToValidUTF8(line, "")
+		}
+		return line, consumed, width, err
+	}
+}
+
+func countColumns(line string, comma rune) int {
+	r := csv.NewReader(strings.NewReader(line))
+	r.Comma = comma
+	r.LazyQuotes = true
+	fields, err := r.Read()
+	if err != nil {
+		return 0
+	}
+	return len(fields)
+}
+
+func describeSample(size int64, stats sampleStats, sections int) string {
+	shown := "head only"
+	switch {
+	case sections == 2:
+		shown = "head and tail"
+	case sections > 2:
+		shown = fmt.Sprintf("head, %d evenly spaced windows and tail", sections-2)
+	}
+	return fmt.Sprintf("[sampled: the file is %s with %d lines (%d blank), the longest of %d bytes; %s shown]",
+		formatBytes(size), stats.lines, stats.blank, stats.longest, shown)
+}
+
+func formatLevels(levels map[string]int) string {
+	names := make([]string, 0, len(levels))
+	for name := range levels {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if levels[names[i]] != levels[names[j]] {
+			return levels[names[i]] > levels[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %d", name, levels[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sampleMarker(from, to int) string {
+	if from == to {
+		return fmt.Sprintf("[... sampled: line %d omitted]", from)
+	}
+	return fmt.Sprintf("[... sampled: lines %d-%d omitted]", from, to)
+}
+
+// sampleFileContent samples a text file larger than maxFileSize within the
+// file prompt budget and marks the node as sampled.
+func (a *Analyzer) sampleFileContent(n *Node) (string, error) {
+	var tokens int
+	if a.aiClient != nil {
+		tokens = a.aiClient.FileContentBudget(n)
+	} else {
+		// Flat mode: the sample is only scanned locally
+		tokens = NewBudget(a.config).FileContentTokens(nil, "")
+	}
+	content, lineMap, err := SampleFile(n.Path, tokens, a.config.Sampling.Windows)
+	if err != nil {
+		return "", err
+	}
+	n.Sampled = true
+	n.sampleLines = lineMap
+	fmt.Printf("\n✂️  Sampled %s, larger than maxFileSize\n", n.Path)
+	return content, nil
+}
+
+// sourceLine maps a line of the content read from a file to its line in the
+// file: sampled content keeps only some of the lines. It returns 0 for the
+// lines added by the sampling.
+func (n *Node) sourceLine(line int) int {
+	if n.sampleLines == nil {
+		return line
+	}
+	if line < 1 || line > len(n.sampleLines) {
+		return 0
+	}
+	return n.sampleLines[line-1]
+}
@@ -0,0 +1,221 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"archi/internal/config"
+)
+
+// SecretFinding is a secret detected in a file. The value itself is never
+// recorded.
+type SecretFinding struct {
+	Line int    `json:"line"`
+	Rule string `json:"rule"`
+}
+
+// secretRule detects one kind of secret. When the pattern has a capture
+// group, only the group is the secret (the key name around it is kept).
+type secretRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// secretKeyNames are the key names whose values are treated as secrets.
+const secretKeyNames = `[a-z0-9_.-]*(?:password|passwd|pwd|secret|api[_-]?key|access[_-]?token|auth[_-]?token|private[_-]?key)[a-z0-9_.-]*`
+
+var builtinSecretRules = []secretRule{
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{"aws-access-key-id", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws-secret-access-key", regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`)},
+	{"google-api-key", regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{"github-token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{50,})\b`)},
+	{"gitlab-token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_\-]{20,}\b`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{"stripe-key", regexp.MustCompile(`\b[rs]k_(?:live|test)_[A-Za-z0-9]{16,}\b`)},
+	{"azure-storage-key", regexp.MustCompile(`AccountKey=([A-Za-z0-9+/=]{60,})`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{"url-credentials", regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s:/@"']+:([^\s@/"']+)@`)},
+	// Quoted values anywhere, e.g. password = "..." in code
+	{"password-assignment", regexp.MustCompile(`(?i)\b` + secretKeyNames + `["']?\s*[:=]\s*["']([^"'\s]{6,})["']`)},
+	// Unquoted values on their own line, e.g. DB_PASSWORD=... in .env, .ini or YAML files
+	{"password-assignment", regexp.MustCompile(`(?im)^\s*(?:export\s+)?` + secretKeyNames + `\s*[:=]\s*([^\s"'#;()]{6,})\s*$`)},
+}
+
+// entropyCandidate matches the long runs of base64-like characters checked
+// for randomness.
+var entropyCandidate = regexp.MustCompile(`(?:^|[^A-Za-z0-9+/_=\-])([A-Za-z0-9+/_\-]{24,128}={0,2})(?:$|[^A-Za-z0-9+/_=\-])`)
+
+// lockFiles hold checksums that look random by design.
+var lockFiles = map[string]bool{
+	"go.sum": true, "package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	"Cargo.lock": true, "poetry.lock": true, "composer.lock": true, "Gemfile.lock": true, "Pipfile.lock": true,
+}
+
+// SecretScanner finds and redacts credentials in file contents.
+type SecretScanner struct {
+	rules   []secretRule
+	entropy float64
+}
+
+// NewSecretScanner returns the scanner configured by cfg, or nil when secret
+// detection is disabled. Custom rules with an invalid pattern are skipped.
+func NewSecretScanner(cfg config.SecretsConfig) *SecretScanner {
+	if !cfg.Enabled {
+		return nil
+	}
+	s := &SecretScanner{rules: builtinSecretRules, entropy: cfg.Entropy}
+	for _, r := range cfg.Rules {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			fmt.Printf("⚠️  Skipping secret rule %s: %v\n", r.Name, err)
+			continue
+		}
+		s.rules = append(s.rules, secretRule{name: r.Name, pattern: re})
+	}
+	return s
+}
+
+// Redact replaces the secrets found in content with [REDACTED:<rule>] and
+// returns the redacted content with the findings, by line.
+func (s *SecretScanner) Redact(path, content string) (string, []SecretFinding) {
+	if s == nil {
+		return content, nil
+	}
+	var spans []redaction
+	for _, r := range s.rules {
+		for _, loc := range r.pattern.FindAllStringSubmatchIndex(content, -1) {
+			start, end := loc[0], loc[1]
+			if len(loc) >= 4 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+			if isSecretPlaceholder(content[start:end]) {
+				continue
+			}
+			spans = append(spans, redaction{start, end, r.name})
+		}
+	}
+	if s.entropy > 0 && !lockFiles[filepath.Base(path)] {
+		for _, loc := range entropyCandidate.FindAllStringSubmatchIndex(content, -1) {
+			if value := content[loc[2]:loc[3]]; looksRandom(value, s.entropy) {
+				spans = append(spans, redaction{loc[2], loc[3], "high-entropy-string"})
+			}
+		}
+	}
+
+	var findings []SecretFinding
+	redacted := redactSpans(content, spans, func(r redaction, line int) string {
+		findings = append(findings, SecretFinding{Line: line, Rule: r.label})
+		return "[REDACTED:" + r.label + "]"
+	})
+	return redacted, findings
+}
+
+// isSecretPlaceholder reports whether an assigned value is a reference or a
+// placeholder rather than a secret, e.g. ${DB_PASSWORD} or <password>.
+func isSecretPlaceholder(value string) bool {
+	if value == "" || strings.Contains(value, "${") || strings.Contains(value, "{{") {
+		return true
+	}
+	switch value[0] {
+	case '$', '{', '<', '%', '*':
+		return true
+	}
+	return strings.Trim(value, "xX*.") == ""
+}
+
+// looksRandom reports whether s mixes upper case letters, lower case letters
+// and digits with a Shannon entropy of at least threshold bits per character.
+// Hexadecimal hashes and identifiers stay below.
+func looksRandom(s string, threshold float64) bool {
+	if strings.HasPrefix(s, "sha") {
+		return false // checksums such as sha512-...
+	}
+	var upper, lower, digit bool
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	if !upper || !lower || !digit {
+		return false
+	}
+	entropy := 0.0
+	for _, c := range counts {
+		p := float64(c) / float64(len(s))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy >= threshold
+}
+
+// redactSecrets redacts the secrets of a file's content and records the
+// findings on its node.
+func (a *Analyzer) redactSecrets(n *Node, content string) string {
+	redacted, findings := a.secrets.Redact(n.Path, content)
+	for i := range findings {
+		findings[i].Line = n.sourceLine(findings[i].Line)
+	}
+	n.Secrets = findings
+	if len(findings) > 0 {
+		fmt.Printf("\n🔐 Redacted %d possible secrets in %s\n", len(findings), n.Path)
+	}
+	return redacted
+}
+
+// generateSecuritySection summarizes the vulnerabilities found by a security
+// review and lists the secrets and the personal data redacted from the
+// files, without their values.
+func generateSecuritySection(root *Node, lang string) string {
+	if root == nil {
+		return ""
+	}
+	body := vulnerabilitiesSubsection(root, lang) + secretsSubsection(root, lang) + personalDataSubsection(root, lang)
+	if body == "" {
+		return ""
+	}
+	return tr(lang, "## Security") + "\n\n" + body
+}
+
+// secretsSubsection lists the secrets found, without their values.
+func secretsSubsection(root *Node, lang string) string {
+	var rows []string
+	files := 0
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if len(n.Secrets) > 0 {
+			files++
+			for _, f := range n.Secrets {
+				rows = append(rows, fmt.Sprintf("| `%s` | %d | %s |\n", escapeTableCell(displayPath(root, n)), f.Line, f.Rule))
+			}
+		}
+		for _, ch := range n.Children {
+			walk(ch)
+		}
+	}
+	walk(root)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	var md strings.Builder
+	md.WriteString(tr(lang, "### Secrets") + "\n\n")
+	md.WriteString(fmt.Sprintf(tr(lang, "%d possible secrets found in %d files. They were redacted before the analysis and are not written to the output.")+"\n\n", len(rows), files))
+	md.WriteString(tr(lang, "| File | Line | Rule |") + "\n")
+	md.WriteString("|---|---:|---|\n")
+	for _, r := range rows {
+		md.WriteString(r)
+	}
+	md.WriteString("\n")
+	return md.String()
+}
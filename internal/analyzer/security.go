@@ -0,0 +1,303 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Analysis profiles select what the file prompt asks for.
+const (
+	ProfileDescription = "description"
+	ProfileSecurity    = "security"
+)
+
+// IsSecurityProfile reports whether profile is "security", which reviews
+// each file for vulnerabilities instead of describing it.
+func IsSecurityProfile(profile string) bool {
+	return strings.ToLower(strings.TrimSpace(profile)) == ProfileSecurity
+}
+
+// normalizeSeverity maps the severities written by models to the known ones.
+func normalizeSeverity(severity string) string {
+	s := strings.ToLower(strings.TrimSpace(severity))
+	if oneOf(s, findingSeverities) {
+		return s
+	}
+	switch s {
+	case "moderate":
+		return "medium"
+	case "informational", "none":
+		return "info"
+	}
+	return "medium"
+}
+
+// SecurityFinding is a possible vulnerability in a file, reported by the
+// security review of the model or by a local heuristic.
+type SecurityFinding struct {
+	Severity string `json:"severity"`
+	// Category is a CWE identifier with its name, e.g. "CWE-89: SQL Injection"
+	Category    string `json:"category"`
+	Title       string `json:"title"`
+	Line        int    `json:"line,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Source is "ai" or "heuristic"
+	Source string `json:"source"`
+}
+
+// SecurityReview is the answer of the security prompt for a file.
+type SecurityReview struct {
+	Summary  string            `json:"summary"`
+	Findings []SecurityFinding `json:"findings"`
+}
+
+// securityHeuristic flags the lines matching a pattern.
+type securityHeuristic struct {
+	category string
+	title    string
+	severity string
+	pattern  *regexp.Regexp
+}
+
+var securityHeuristics = []securityHeuristic{
+	// Disabled TLS verification
+	{"CWE-295: Improper Certificate Validation", "TLS certificate verification disabled", "high",
+		regexp.MustCompile(`InsecureSkipVerify\s*:\s*true|verify\s*=\s*False|rejectUnauthorized\s*:\s*false|NODE_TLS_REJECT_UNAUTHORIZED\s*=\s*["']?0|CURLOPT_SSL_VERIFY(?:PEER|HOST)\s*,\s*(?:false|0)|ssl\._create_unverified_context|CERT_NONE|ALLOW_ALL_HOSTNAME_VERIFIER|NoopHostnameVerifier|ServerCertificateValidationCallback\s*=.*=>\s*true|curl\s[^\n]*(?:\s-k\b|--insecure)`)},
+	// Dangerous function calls
+	{"CWE-95: Eval Injection", "Dynamic code evaluation", "high",
+		regexp.MustCompile(`(?:^|[^\w.])(?:eval|exec)\s*\(|new\s+Function\s*\(|setTimeout\s*\(\s*["']`)},
+	{"CWE-78: OS Command Injection", "Shell command execution", "high",
+		regexp.MustCompile(`os\.system\s*\(|os\.popen\s*\(|subprocess\.\w+\([^\n]*shell\s*=\s*True|child_process|\bexecSync\s*\(|Runtime\.getRuntime\(\)\.exec|exec\.Command\(\s*"(?:sh|bash|cmd)"|\b(?:shell_exec|passthru|proc_open)\s*\(|\bsystem\s*\(`)},
+	{"CWE-502: Deserialization of Untrusted Data", "Unsafe deserialization", "high",
+		regexp.MustCompile(`pickle\.loads?\s*\(|cPickle\.loads?\s*\(|yaml\.load\s*\((?:[^)\n]*Loader\s*=\s*yaml\.(?:Unsafe|Full)?Loader|[^)\n]*\))|\bunserialize\s*\(|ObjectInputStream|BinaryFormatter|Marshal\.load`)},
+	{"CWE-79: Cross-site Scripting", "HTML written from code", "medium",
+		regexp.MustCompile(`\.innerHTML\s*=|\.outerHTML\s*=|document\.write\s*\(|dangerouslySetInnerHTML|v-html\s*=|template\.HTML\s*\(|\|\s*safe\b|mark_safe\s*\(`)},
+	{"CWE-89: SQL Injection", "SQL query built from strings", "medium",
+		regexp.MustCompile(`(?i)(?:execute|query|exec|raw)\s*\(\s*(?:f["']|["'](?:select|insert|update|delete)\b[^"'\n]*["']\s*(?:\+|%|\.format))|(?:Sprintf|format)\(\s*["'](?:select|insert|update|delete)\s`)},
+	{"CWE-120: Buffer Copy without Checking Size of Input", "Unbounded C string function", "medium",
+		regexp.MustCompile(`\b(?:strcpy|strcat|sprintf|vsprintf|gets)\s*\(`)},
+	{"CWE-327: Use of a Broken or Risky Cryptographic Algorithm", "Weak hash or cipher", "low",
+		regexp.MustCompile(`(?i)\b(?:md5|sha1)\s*\(|crypto/(?:md5|sha1|des|rc4)\b|hashlib\.(?:md5|sha1)\b|MessageDigest\.getInstance\(\s*"(?:MD5|SHA-?1)"|createHash\(\s*["'](?:md5|sha1)["']|Cipher\.getInstance\(\s*"(?:DES|RC4|AES/ECB)`)},
+}
+
+// urlRe matches hard-coded URLs; urlIgnored are the hosts of local, example
+// and schema URLs.
+var (
+	urlRe      = regexp.MustCompile(`\bhttps?://[^\s"'<>()\[\]{}\x60,;]+`)
+	urlIgnored = regexp.MustCompile(`^https?://(?:localhost|127\.0\.0\.1|0\.0\.0\.0|\[::1\]|(?:[\w-]+\.)*example\.(?:com|org|net)|(?:www\.)?w3\.org|json-schema\.org|schemas\.[\w.-]+|xmlns\.[\w.-]+)(?:[:/]|$)`)
+)
+
+// checkedBySecurityHeuristics reports whether a file is source code or
+// configuration; prose and data files are not checked.
+func checkedBySecurityHeuristics(name string) bool {
+	switch detectLanguage(name) {
+	case "", "Markdown", "reStructuredText", "TeX", "Text", "CSV", "Go Module":
+		return false
+	}
+	return true
+}
+
+// ScanSecurityHeuristics flags the dangerous calls, disabled TLS
+// verifications and hard-coded URLs of a source file, by line.
+func ScanSecurityHeuristics(name, content string) []SecurityFinding {
+	if !checkedBySecurityHeuristics(name) {
+		return nil
+	}
+	var findings []SecurityFinding
+	seenURLs := make(map[string]bool)
+	for i, line := range strings.Split(content, "\n") {
+		for _, h := range securityHeuristics {
+			if h.pattern.MatchString(line) {
+				findings = append(findings, SecurityFinding{Severity: h.severity, Category: h.category, Title: h.title, Line: i + 1, Source: "heuristic"})
+			}
+		}
+		for _, url := range urlRe.FindAllString(line, -1) {
+			url = strings.TrimRight(url, ".")
+			if urlIgnored.MatchString(url) || seenURLs[url] {
+				continue
+			}
+			seenURLs[url] = true
+			f := SecurityFinding{Severity: "info", Category: "CWE-547: Use of Hard-coded, Security-relevant Constants", Title: "Hard-coded URL", Line: i + 1, Description: url, Source: "heuristic"}
+			if strings.HasPrefix(url, "http://") {
+				f.Severity, f.Category, f.Title = "low", "CWE-319: Cleartext Transmission of Sensitive Information", "Hard-coded plain HTTP URL"
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// scanFileSecurity runs the heuristics on the content read from a file, with
+// the lines of a sample mapped back to the file.
+func scanFileSecurity(n *Node, content string) []SecurityFinding {
+	findings := ScanSecurityHeuristics(n.Name, content)
+	for i := range findings {
+		findings[i].Line = n.sourceLine(findings[i].Line)
+	}
+	return findings
+}
+
+// numberLines prefixes each line with its number in the file, for the line
+// hints of the security prompt. Lines that are not from the file, such as the
+// markers of a sample, get no number.
+func numberLines(content string, sourceLine func(int) int) string {
+	lines := strings.Split(content, "\n")
+	numbers := make([]string, len(lines))
+	width := 0
+	for i := range lines {
+		if line := sourceLine(i + 1); line > 0 {
+			numbers[i] = fmt.Sprint(line)
+			width = max(width, len(numbers[i]))
+		}
+	}
+	for i, line := range lines {
+		lines[i] = fmt.Sprintf("%*s| %s", width, numbers[i], line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// reviewFileSecurity runs the security prompt on a file's content, adding its
+// findings to the heuristic ones of the node and using the review summary as
+// its description.
+func (a *Analyzer) reviewFileSecurity(n *Node, content string) error {
+	review, err := a.aiClient.ReviewFileSecurity(content, n)
+	if err != nil {
+		return err
+	}
+	n.Description = review.Summary
+	n.Security = append(n.Security, review.Findings...)
+	sortSecurityFindings(n.Security)
+	return nil
+}
+
+// scanSecurityLocally runs the heuristics on the redacted content of the
+// files, without the AI review.
+func (a *Analyzer) scanSecurityLocally(fileNodes []*Node) {
+	for _, n := range fileNodes {
+		info, err := os.Stat(n.Path)
+		if err != nil || !checkedBySecurityHeuristics(n.Name) {
+			continue
+		}
+		content, err := a.extractFileContent(n, info)
+		if err != nil || content == "" {
+			continue
+		}
+		n.Security = scanFileSecurity(n, a.redactSecrets(n, content))
+	}
+}
+
+func sortSecurityFindings(findings []SecurityFinding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if ri, rj := severityRank(findings[i].Severity), severityRank(findings[j].Severity); ri != rj {
+			return ri < rj
+		}
+		return findings[i].Line < findings[j].Line
+	})
+}
+
+// RankedSecurityFinding is a finding with the path of its file.
+type RankedSecurityFinding struct {
+	Path string `json:"path"`
+	SecurityFinding
+}
+
+// FindSecurityFindings collects the findings of the tree, the most severe
+// first, then by path and line.
+func FindSecurityFindings(root *Node) []RankedSecurityFinding {
+	if root == nil {
+		return nil
+	}
+	var findings []RankedSecurityFinding
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if n.ChangeStatus != StatusDeleted {
+			for _, f := range n.Security {
+				findings = append(findings, RankedSecurityFinding{Path: displayPath(root, n), SecurityFinding: f})
+			}
+		}
+		for _, ch := range n.Children {
+			walk(ch)
+		}
+	}
+	walk(root)
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if ra, rb := severityRank(a.Severity), severityRank(b.Severity); ra != rb {
+			return ra < rb
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Line < b.Line
+	})
+	return findings
+}
+
+// countBySeverity formats the number of findings per severity, e.g.
+// "2 high, 5 low".
+func countBySeverity(findings []RankedSecurityFinding, lang string) string {
+	counts := make(map[string]int)
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+	var parts []string
+	for _, s := range findingSeverities {
+		if counts[s] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[s], tr(lang, s)))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// vulnerabilitiesSubsection summarizes the findings by severity in output.md;
+// the security report lists them.
+func vulnerabilitiesSubsection(root *Node, lang string) string {
+	findings := FindSecurityFindings(root)
+	if len(findings) == 0 {
+		return ""
+	}
+	return tr(lang, "### Vulnerabilities") + "\n\n" +
+		fmt.Sprintf(tr(lang, "%d possible vulnerabilities found by the security review: %s.")+"\n\n", len(findings), countBySeverity(findings, lang))
+}
+
+// GenerateSecurityReport renders the findings of a security review ranked by
+// severity, followed by the secrets and personal data redacted.
+func GenerateSecurityReport(root *Node, lang string) string {
+	findings := FindSecurityFindings(root)
+
+	var md strings.Builder
+	md.WriteString(tr(lang, "# Security Report") + "\n\n")
+	md.WriteString(tr(lang, "## Vulnerabilities") + "\n\n")
+	if len(findings) == 0 {
+		md.WriteString(tr(lang, "No vulnerabilities were found.") + "\n\n")
+	} else {
+		md.WriteString(fmt.Sprintf(tr(lang, "%d possible vulnerabilities found by the security review: %s.")+"\n\n", len(findings), countBySeverity(findings, lang)))
+		md.WriteString(tr(lang, "Findings marked *heuristic* come from local pattern matching and may be false positives; the others come from the AI review.") + "\n\n")
+		md.WriteString(tr(lang, "| Severity | File | Line | Category | Finding |") + "\n")
+		md.WriteString("|---|---|---:|---|---|\n")
+		for _, f := range findings {
+			line := ""
+			if f.Line > 0 {
+				line = fmt.Sprint(f.Line)
+			}
+			finding := f.Title
+			if f.Description != "" {
+				finding += ": " + f.Description
+			}
+			if f.Source == "heuristic" {
+				finding += " *(" + tr(lang, "heuristic") + ")*"
+			}
+			md.WriteString(fmt.Sprintf("| %s | `%s` | %s | %s | %s |\n", tr(lang, f.Severity), escapeTableCell(f.Path), line, escapeTableCell(f.Category), escapeTableCell(finding)))
+		}
+		md.WriteString("\n")
+	}
+	if root != nil {
+		if redacted := secretsSubsection(root, lang) + personalDataSubsection(root, lang); redacted != "" {
+			md.WriteString(tr(lang, "## Redacted Data") + "\n\n" + redacted)
+		}
+	}
+	return md.String()
+}
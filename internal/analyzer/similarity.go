@@ -0,0 +1,330 @@
+package analyzer
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	// shingleSize is the number of consecutive words hashed together.
+	shingleSize = 5
+	// minHashSize is the number of hash functions of a signature.
+	minHashSize = 128
+	// lshBands splits the signatures into bands; files sharing a band are
+	// compared. With 4 rows per band, pairs above ~0.5 similarity are found.
+	lshBands = 32
+)
+
+// SimilarFile is a file whose content is close to the content of another.
+type SimilarFile struct {
+	Path string `json:"path"`
+	// Similarity is the estimated Jaccard similarity of the word shingles (0 to 1)
+	Similarity float64 `json:"similarity"`
+}
+
+// NearDuplicateCluster is a group of files linked by near-duplicate pairs.
+type NearDuplicateCluster struct {
+	Paths         []string      `json:"paths"`
+	Pairs         []SimilarPair `json:"pairs"`
+	MinSimilarity float64       `json:"minSimilarity"`
+	MaxSimilarity float64       `json:"maxSimilarity"`
+}
+
+// SimilarPair is the similarity of two files of a cluster.
+type SimilarPair struct {
+	A          string  `json:"a"`
+	B          string  `json:"b"`
+	Similarity float64 `json:"similarity"`
+}
+
+// minHashSeeds seeds the hash functions of the signatures. They are fixed so
+// that signatures are reproducible across runs.
+var minHashSeeds = func() [minHashSize]uint64 {
+	var seeds [minHashSize]uint64
+	state := uint64(0x9e3779b97f4a7c15)
+	for i := range seeds {
+		state += 0x9e3779b97f4a7c15
+		seeds[i] = mix64(state)
+	}
+	return seeds
+}()
+
+// mix64 is the splitmix64 finalizer.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+// minHashSignature returns the MinHash signature of the shingles of the
+// lower-cased words of text, or nil when text has fewer than minWords words.
+// Punctuation and whitespace are ignored, so reformatted copies still match.
+func minHashSignature(text string, minWords int) []uint64 {
+	var words []uint64
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		h := fnv.New64a()
+		h.Write([]byte(w))
+		words = append(words, h.Sum64())
+	}
+	if len(words) < max(minWords, shingleSize) {
+		return nil
+	}
+
+	sig := make([]uint64, minHashSize)
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+	for i := 0; i+shingleSize <= len(words); i++ {
+		var shingle uint64
+		for _, w := range words[i : i+shingleSize] {
+			shingle = mix64(shingle ^ w)
+		}
+		for j, seed := range minHashSeeds {
+			if v := mix64(shingle ^ seed); v < sig[j] {
+				sig[j] = v
+			}
+		}
+	}
+	return sig
+}
+
+// signatureSimilarity estimates the Jaccard similarity of two signatures.
+func signatureSimilarity(a, b []uint64) float64 {
+	equal := 0
+	for i := range a {
+		if a[i] == b[i] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(a))
+}
+
+// findNearDuplicates links the files whose extracted text is similar above
+// the configured threshold, recording the links in Node.Similar. Candidate
+// pairs come from locality-sensitive hashing of the MinHash signatures, so
+// files are not compared two by two.
+func (a *Analyzer) findNearDuplicates(fileNodes []*Node) {
+	cfg := a.config.NearDuplicates
+	if !cfg.Enabled || len(fileNodes) < 2 {
+		return
+	}
+
+	fmt.Printf("\n🧩 Looking for near-duplicate files...\n")
+	sigs := make([][]uint64, len(fileNodes))
+	sem := make(chan struct{}, a.config.BatchSize)
+	var wg sync.WaitGroup
+	for i, n := range fileNodes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, n *Node) {
+			defer func() { <-sem; wg.Done() }()
+			info, err := os.Stat(n.Path)
+			// Large files would be sampled, and the windows of two samples do not line up
+			if err != nil || info.Size() > a.config.MaxFileSize {
+				return
+			}
+			// The signatures stay local, so the text is not redacted
+			content, err := a.readFileContent(n.Path, info)
+			if err != nil || content == "" {
+				return
+			}
+			if !textExtensions[strings.ToLower(filepath.Ext(n.Path))] {
+				n.text = content
+			}
+			sigs[i] = minHashSignature(content, cfg.MinWords)
+		}(i, n)
+	}
+	wg.Wait()
+
+	type bucket struct {
+		band int
+		hash uint64
+	}
+	buckets := make(map[bucket][]int)
+	rows := minHashSize / lshBands
+	compared := 0
+	for i, sig := range sigs {
+		if sig == nil {
+			continue
+		}
+		compared++
+		for b := 0; b < lshBands; b++ {
+			var h uint64
+			for _, v := range sig[b*rows : (b+1)*rows] {
+				h = mix64(h ^ v)
+			}
+			key := bucket{b, h}
+			buckets[key] = append(buckets[key], i)
+		}
+	}
+
+	checked := make(map[[2]int]bool)
+	linked := make(map[*Node]bool)
+	for _, files := range buckets {
+		for x := 0; x < len(files); x++ {
+			for y := x + 1; y < len(files); y++ {
+				pair := [2]int{files[x], files[y]}
+				if checked[pair] {
+					continue
+				}
+				checked[pair] = true
+				sim := signatureSimilarity(sigs[pair[0]], sigs[pair[1]])
+				if sim < cfg.Threshold {
+					continue
+				}
+				n, m := fileNodes[pair[0]], fileNodes[pair[1]]
+				n.Similar = append(n.Similar, SimilarFile{Path: m.Path, Similarity: sim})
+				m.Similar = append(m.Similar, SimilarFile{Path: n.Path, Similarity: sim})
+				linked[n], linked[m] = true, true
+			}
+		}
+	}
+	for n := range linked {
+		sort.Slice(n.Similar, func(i, j int) bool {
+			if n.Similar[i].Similarity != n.Similar[j].Similarity {
+				return n.Similar[i].Similarity > n.Similar[j].Similarity
+			}
+			return n.Similar[i].Path < n.Similar[j].Path
+		})
+	}
+	fmt.Printf("   %d files compared, %d have near duplicates\n", compared, len(linked))
+}
+
+// FindNearDuplicates groups the files linked in the tree by near-duplicate
+// pairs into clusters, the largest and most similar first.
+func FindNearDuplicates(root *Node) []NearDuplicateCluster {
+	if root == nil {
+		return nil
+	}
+	byPath := make(map[string]*Node)
+	var files []*Node
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if n.Type == "file" && n.ChangeStatus != StatusDeleted {
+			byPath[n.Path] = n
+			if len(n.Similar) > 0 {
+				files = append(files, n)
+			}
+		}
+		for _, ch := range n.Children {
+			walk(ch)
+		}
+	}
+	walk(root)
+
+	parent := make(map[string]string)
+	var find func(p string) string
+	find = func(p string) string {
+		if q, ok := parent[p]; ok && q != p {
+			parent[p] = find(q)
+			return parent[p]
+		}
+		return p
+	}
+	for _, n := range files {
+		for _, s := range n.Similar {
+			if byPath[s.Path] == nil {
+				continue
+			}
+			if ra, rb := find(n.Path), find(s.Path); ra != rb {
+				parent[ra] = rb
+			}
+		}
+	}
+
+	members := make(map[string][]*Node)
+	var roots []string
+	for _, n := range files {
+		r := find(n.Path)
+		if _, ok := members[r]; !ok {
+			roots = append(roots, r)
+		}
+		members[r] = append(members[r], n)
+	}
+
+	var clusters []NearDuplicateCluster
+	for _, r := range roots {
+		c := NearDuplicateCluster{MinSimilarity: 1}
+		for _, n := range members[r] {
+			c.Paths = append(c.Paths, displayPath(root, n))
+			for _, s := range n.Similar {
+				// Each pair is listed by both files; keep it once
+				if other := byPath[s.Path]; other != nil && n.Path < s.Path {
+					c.Pairs = append(c.Pairs, SimilarPair{A: displayPath(root, n), B: displayPath(root, other), Similarity: s.Similarity})
+					c.MinSimilarity = math.Min(c.MinSimilarity, s.Similarity)
+					c.MaxSimilarity = math.Max(c.MaxSimilarity, s.Similarity)
+				}
+			}
+		}
+		if len(c.Pairs) == 0 {
+			continue
+		}
+		sort.Slice(c.Pairs, func(i, j int) bool { return c.Pairs[i].Similarity > c.Pairs[j].Similarity })
+		clusters = append(clusters, c)
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if len(clusters[i].Paths) != len(clusters[j].Paths) {
+			return len(clusters[i].Paths) > len(clusters[j].Paths)
+		}
+		return clusters[i].MaxSimilarity > clusters[j].MaxSimilarity
+	})
+	return clusters
+}
+
+// similarityRange formats the similarity of a cluster as a percentage range.
+func similarityRange(c NearDuplicateCluster) string {
+	low, high := math.Round(c.MinSimilarity*100), math.Round(c.MaxSimilarity*100)
+	if low == high {
+		return fmt.Sprintf("%.0f%%", high)
+	}
+	return fmt.Sprintf("%.0f–%.0f%%", low, high)
+}
+
+// FormatNearDuplicates lists the largest near-duplicate clusters for the
+// architecture prompt, one per line.
+func FormatNearDuplicates(root *Node, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	clusters := FindNearDuplicates(root)
+	if len(clusters) > limit {
+		clusters = clusters[:limit]
+	}
+	var sb strings.Builder
+	for _, c := range clusters {
+		sb.WriteString(fmt.Sprintf("%s: %d files, %s similar\n", strings.Join(c.Paths, ", "), len(c.Paths), similarityRange(c)))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// generateNearDuplicatesSection lists the near-duplicate clusters.
+func generateNearDuplicatesSection(clusters []NearDuplicateCluster, lang string) string {
+	if len(clusters) == 0 {
+		return ""
+	}
+	var md strings.Builder
+	md.WriteString(tr(lang, "## Near Duplicates") + "\n\n")
+	md.WriteString(tr(lang, "Groups of files whose content is mostly identical, with the estimated similarity of their text.") + "\n\n")
+	md.WriteString(tr(lang, "| Files | Similarity |") + "\n")
+	md.WriteString("|---|---:|\n")
+	for _, c := range clusters {
+		paths := make([]string, len(c.Paths))
+		for i, p := range c.Paths {
+			paths[i] = "`" + escapeTableCell(p) + "`"
+		}
+		md.WriteString(fmt.Sprintf("| %s | %s |\n", strings.Join(paths, "<br>"), similarityRange(c)))
+	}
+	md.WriteString("\n")
+	return md.String()
+}
@@ -0,0 +1,375 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// significantDescriptionSimilarity is the word-set Jaccard similarity below
+// which the description of a changed node is reported as changed. Regenerated
+// descriptions of the same content often share less than half of their words.
+const significantDescriptionSimilarity = 0.2
+
+// SnapshotDiff is the structural comparison of two analyses. Paths are
+// relative to the root of each analysis.
+type SnapshotDiff struct {
+	Old      string             `json:"old"`
+	New      string             `json:"new"`
+	Added    []SnapshotEntry    `json:"added"`
+	Removed  []SnapshotEntry    `json:"removed"`
+	Moved    []SnapshotMove     `json:"moved"`
+	Modified []SnapshotModified `json:"modified"`
+	// Descriptions lists the changed nodes present in both analyses whose description changed significantly
+	Descriptions []SnapshotDescription `json:"descriptions"`
+	Folders      []FolderDelta         `json:"folders"`
+	// Summary is the AI-written summary of the evolution, when requested
+	Summary string `json:"summary,omitempty"`
+}
+
+// SnapshotEntry is a node present in only one of the analyses.
+type SnapshotEntry struct {
+	Path        string `json:"path"`
+	Type        string `json:"type"`
+	Size        int64  `json:"size,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// SnapshotMove is a file found at another path with the same content hash.
+type SnapshotMove struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	SHA256 string `json:"sha256"`
+}
+
+// SnapshotModified is a file whose content hash changed.
+type SnapshotModified struct {
+	Path      string `json:"path"`
+	OldSize   int64  `json:"oldSize"`
+	NewSize   int64  `json:"newSize"`
+	OldSHA256 string `json:"oldSha256"`
+	NewSHA256 string `json:"newSha256"`
+}
+
+// SnapshotDescription is a description that changed between the analyses.
+type SnapshotDescription struct {
+	Path       string  `json:"path"`
+	Type       string  `json:"type"`
+	Similarity float64 `json:"similarity"`
+	Old        string  `json:"old"`
+	New        string  `json:"new"`
+}
+
+// FolderDelta is the change in size and file count of a folder.
+type FolderDelta struct {
+	Path     string `json:"path"`
+	OldSize  int64  `json:"oldSize"`
+	NewSize  int64  `json:"newSize"`
+	OldFiles int    `json:"oldFiles"`
+	NewFiles int    `json:"newFiles"`
+}
+
+// snapshotNode is a node with the totals needed for the comparison.
+type snapshotNode struct {
+	node  *Node
+	size  int64
+	files int
+}
+
+// indexSnapshot maps the nodes of an analysis by path relative to its root,
+// computing folder sizes and file counts from the files below them.
+func indexSnapshot(root *Node) map[string]*snapshotNode {
+	index := make(map[string]*snapshotNode)
+	var walk func(n *Node) *snapshotNode
+	walk = func(n *Node) *snapshotNode {
+		s := &snapshotNode{node: n}
+		if n.Type == "directory" {
+			for _, ch := range n.Children {
+				cs := walk(ch)
+				s.size += cs.size
+				s.files += cs.files
+			}
+		} else {
+			s.files = 1
+			if n.Metadata != nil {
+				s.size = n.Metadata.Size
+			}
+		}
+		// Deleted nodes of a --since analysis are not part of the snapshot
+		if n.ChangeStatus != StatusDeleted {
+			index[relativeTo(root.Path, n.Path)] = s
+		} else {
+			s.size, s.files = 0, 0
+		}
+		return s
+	}
+	if root != nil {
+		walk(root)
+	}
+	return index
+}
+
+func nodeSHA256(n *Node) string {
+	if n.Metadata == nil {
+		return ""
+	}
+	return n.Metadata.SHA256
+}
+
+// DiffSnapshots compares two analyses: nodes added and removed, files moved
+// (same SHA-256 at another path), files modified (different SHA-256), changed
+// nodes whose description changed significantly, and folders whose size or file
+// count changed. Moves and modifications need the metadata of both analyses.
+func DiffSnapshots(oldRoot, newRoot *Node) *SnapshotDiff {
+	d := &SnapshotDiff{}
+	if oldRoot != nil {
+		d.Old = oldRoot.Path
+	}
+	if newRoot != nil {
+		d.New = newRoot.Path
+	}
+	oldIndex, newIndex := indexSnapshot(oldRoot), indexSnapshot(newRoot)
+
+	var removed, added []string
+	for p := range oldIndex {
+		if _, ok := newIndex[p]; !ok {
+			removed = append(removed, p)
+		}
+	}
+	for p := range newIndex {
+		if _, ok := oldIndex[p]; !ok {
+			added = append(added, p)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+
+	// Pair removed and added files with the same content
+	addedByHash := make(map[string][]string)
+	for _, p := range added {
+		if n := newIndex[p].node; n.Type == "file" && nodeSHA256(n) != "" {
+			addedByHash[nodeSHA256(n)] = append(addedByHash[nodeSHA256(n)], p)
+		}
+	}
+	moved := make(map[string]bool)
+	for _, p := range removed {
+		n := oldIndex[p].node
+		hash := nodeSHA256(n)
+		if n.Type != "file" || hash == "" || len(addedByHash[hash]) == 0 {
+			continue
+		}
+		to := addedByHash[hash][0]
+		addedByHash[hash] = addedByHash[hash][1:]
+		d.Moved = append(d.Moved, SnapshotMove{From: p, To: to, SHA256: hash})
+		moved[p], moved[to] = true, true
+	}
+
+	entry := func(p string, s *snapshotNode) SnapshotEntry {
+		return SnapshotEntry{Path: p, Type: s.node.Type, Size: s.size, Description: s.node.Description}
+	}
+	for _, p := range removed {
+		if !moved[p] {
+			d.Removed = append(d.Removed, entry(p, oldIndex[p]))
+		}
+	}
+	for _, p := range added {
+		if !moved[p] {
+			d.Added = append(d.Added, entry(p, newIndex[p]))
+		}
+	}
+
+	var common []string
+	for p := range newIndex {
+		if _, ok := oldIndex[p]; ok {
+			common = append(common, p)
+		}
+	}
+	sort.Strings(common)
+	changed := contentChanged(oldRoot, newRoot, oldIndex, newIndex)
+	for _, p := range common {
+		o, n := oldIndex[p], newIndex[p]
+		if o.node.Type == "file" && n.node.Type == "file" {
+			if oh, nh := nodeSHA256(o.node), nodeSHA256(n.node); oh != "" && nh != "" && oh != nh {
+				d.Modified = append(d.Modified, SnapshotModified{Path: p, OldSize: o.size, NewSize: n.size, OldSHA256: oh, NewSHA256: nh})
+			}
+		}
+		if changed(p) && o.node.Description != "" && n.node.Description != "" {
+			if sim := descriptionSimilarity(o.node.Description, n.node.Description); sim < significantDescriptionSimilarity {
+				d.Descriptions = append(d.Descriptions, SnapshotDescription{Path: p, Type: n.node.Type, Similarity: sim, Old: o.node.Description, New: n.node.Description})
+			}
+		}
+		if o.node.Type == "directory" && n.node.Type == "directory" && (o.size != n.size || o.files != n.files) {
+			d.Folders = append(d.Folders, FolderDelta{Path: p, OldSize: o.size, NewSize: n.size, OldFiles: o.files, NewFiles: n.files})
+		}
+	}
+	// Largest size changes first, then largest file count changes
+	sort.SliceStable(d.Folders, func(i, j int) bool {
+		di, dj := abs64(d.Folders[i].NewSize-d.Folders[i].OldSize), abs64(d.Folders[j].NewSize-d.Folders[j].OldSize)
+		if di != dj {
+			return di > dj
+		}
+		return abs64(int64(d.Folders[i].NewFiles-d.Folders[i].OldFiles)) > abs64(int64(d.Folders[j].NewFiles-d.Folders[j].OldFiles))
+	})
+	return d
+}
+
+// contentChanged returns whether the node at a path present in both analyses
+// changed: a file whose SHA-256 differs or is unknown, a folder whose children
+// are not the same or changed.
+func contentChanged(oldRoot, newRoot *Node, oldIndex, newIndex map[string]*snapshotNode) func(p string) bool {
+	memo := make(map[string]bool)
+	var changed func(p string) bool
+	changed = func(p string) bool {
+		if c, ok := memo[p]; ok {
+			return c
+		}
+		o, n := oldIndex[p], newIndex[p]
+		c := false
+		switch {
+		case o == nil || n == nil || o.node.Type != n.node.Type:
+			c = true
+		case n.node.Type != "directory":
+			oh, nh := nodeSHA256(o.node), nodeSHA256(n.node)
+			c = oh == "" || oh != nh
+		default:
+			children := make(map[string]bool)
+			for _, ch := range o.node.Children {
+				if ch.ChangeStatus != StatusDeleted {
+					children[relativeTo(oldRoot.Path, ch.Path)] = true
+				}
+			}
+			count := 0
+			for _, ch := range n.node.Children {
+				if ch.ChangeStatus == StatusDeleted {
+					continue
+				}
+				cp := relativeTo(newRoot.Path, ch.Path)
+				count++
+				if !children[cp] || changed(cp) {
+					c = true
+				}
+			}
+			c = c || count != len(children)
+		}
+		memo[p] = c
+		return c
+	}
+	return changed
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// descriptionSimilarity is the Jaccard similarity of the lower-cased word
+// sets of two descriptions.
+func descriptionSimilarity(a, b string) float64 {
+	words := func(s string) map[string]bool {
+		set := make(map[string]bool)
+		for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			set[w] = true
+		}
+		return set
+	}
+	wa, wb := words(a), words(b)
+	if len(wa) == 0 && len(wb) == 0 {
+		return 1
+	}
+	inter := 0
+	for w := range wa {
+		if wb[w] {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(wa)+len(wb)-inter)
+}
+
+// GenerateSnapshotDiffMarkdown renders a snapshot comparison.
+func GenerateSnapshotDiffMarkdown(d *SnapshotDiff, lang string) string {
+	var md strings.Builder
+	md.WriteString(tr(lang, "# Analysis Comparison") + "\n\n")
+	md.WriteString(fmt.Sprintf(tr(lang, "Comparing `%s` (old) with `%s` (new).")+"\n\n", d.Old, d.New))
+	md.WriteString(tr(lang, "## Summary") + "\n\n")
+	md.WriteString(fmt.Sprintf(tr(lang, "- **Added:** %d")+"\n", len(d.Added)))
+	md.WriteString(fmt.Sprintf(tr(lang, "- **Removed:** %d")+"\n", len(d.Removed)))
+	md.WriteString(fmt.Sprintf(tr(lang, "- **Moved:** %d")+"\n", len(d.Moved)))
+	md.WriteString(fmt.Sprintf(tr(lang, "- **Modified:** %d")+"\n", len(d.Modified)))
+	md.WriteString(fmt.Sprintf(tr(lang, "- **Changed descriptions:** %d")+"\n", len(d.Descriptions)))
+	md.WriteString(fmt.Sprintf(tr(lang, "- **Folders with size changes:** %d")+"\n\n", len(d.Folders)))
+
+	if d.Summary != "" {
+		md.WriteString(tr(lang, "## Evolution") + "\n\n")
+		md.WriteString(strings.TrimSpace(d.Summary) + "\n\n")
+	}
+
+	entries := func(title string, list []SnapshotEntry) {
+		if len(list) == 0 {
+			return
+		}
+		md.WriteString(tr(lang, title) + "\n\n")
+		for _, e := range list {
+			name := e.Path
+			if e.Type == "directory" {
+				name += "/"
+			}
+			md.WriteString(fmt.Sprintf("- `%s`", name))
+			if e.Description != "" {
+				md.WriteString(" — " + firstSentence(e.Description))
+			}
+			md.WriteString("\n")
+		}
+		md.WriteString("\n")
+	}
+	entries("## Added", d.Added)
+	entries("## Removed", d.Removed)
+
+	if len(d.Moved) > 0 {
+		md.WriteString(tr(lang, "## Moved") + "\n\n")
+		for _, m := range d.Moved {
+			md.WriteString(fmt.Sprintf("- `%s` → `%s`\n", m.From, m.To))
+		}
+		md.WriteString("\n")
+	}
+
+	if len(d.Modified) > 0 {
+		md.WriteString(tr(lang, "## Modified") + "\n\n")
+		md.WriteString(tr(lang, "| File | Old size | New size |") + "\n")
+		md.WriteString("|---|---:|---:|\n")
+		for _, m := range d.Modified {
+			md.WriteString(fmt.Sprintf("| `%s` | %s | %s |\n", escapeTableCell(m.Path), formatBytes(m.OldSize), formatBytes(m.NewSize)))
+		}
+		md.WriteString("\n")
+	}
+
+	if len(d.Descriptions) > 0 {
+		md.WriteString(tr(lang, "## Changed Descriptions") + "\n\n")
+		for _, c := range d.Descriptions {
+			md.WriteString(fmt.Sprintf("### `%s` (%.0f%%)\n\n", c.Path, c.Similarity*100))
+			md.WriteString(fmt.Sprintf(tr(lang, "- **Before:** %s")+"\n", firstSentence(c.Old)))
+			md.WriteString(fmt.Sprintf(tr(lang, "- **After:** %s")+"\n\n", firstSentence(c.New)))
+		}
+	}
+
+	if len(d.Folders) > 0 {
+		md.WriteString(tr(lang, "## Folder Size Changes") + "\n\n")
+		md.WriteString(tr(lang, "| Folder | Old size | New size | Change | Files |") + "\n")
+		md.WriteString("|---|---:|---:|---:|---:|\n")
+		for _, f := range d.Folders {
+			delta := f.NewSize - f.OldSize
+			sign := "+"
+			if delta < 0 {
+				sign = "-"
+			}
+			md.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s%s | %d → %d |\n", escapeTableCell(f.Path+"/"),
+				formatBytes(f.OldSize), formatBytes(f.NewSize), sign, formatBytes(abs64(delta)), f.OldFiles, f.NewFiles))
+		}
+		md.WriteString("\n")
+	}
+	return md.String()
+}
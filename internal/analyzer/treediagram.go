@@ -0,0 +1,159 @@
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"archi/internal/config"
+)
+
+const maxTooltipRunes = 160
+
+// diagramNode is a node of the tree after depth cutoff and folder collapsing
+// have been applied.
+type diagramNode struct {
+	id       string
+	label    string
+	tooltip  string
+	isDir    bool
+	children []*diagramNode
+}
+
+// buildDiagramTree applies the depth cutoff and collapse threshold from opts.
+// Folders beyond the cutoff or with too many entries are kept as a single node
+// whose label carries the number of hidden items.
+func buildDiagramTree(root *Node, opts config.TreeDiagramConfig) *diagramNode {
+	next := 0
+	var build func(n *Node, depth int) *diagramNode
+	build = func(n *Node, depth int) *diagramNode {
+		d := &diagramNode{
+			id:      fmt.Sprintf("n%d", next),
+			label:   nodeIcon(n) + " " + n.Name,
+			tooltip: firstSentence(n.Description),
+			isDir:   n.Type == "directory",
+		}
+		next++
+		if len(n.Children) == 0 {
+			return d
+		}
+		collapsed := (opts.MaxDepth > 0 && depth >= opts.MaxDepth) ||
+			(opts.CollapseThreshold > 0 && len(n.Children) > opts.CollapseThreshold)
+		if collapsed {
+			d.label += fmt.Sprintf(" (+%d items)", countDescendants(n))
+			return d
+		}
+		for _, child := range n.Children {
+			d.children = append(d.children, build(child, depth+1))
+		}
+		return d
+	}
+	return build(root, 0)
+}
+
+func countDescendants(n *Node) int {
+	count := 0
+	for _, child := range n.Children {
+		count += 1 + countDescendants(child)
+	}
+	return count
+}
+
+// firstSentence returns the first sentence of a description, stripped of
+// markdown emphasis and capped for use as a tooltip.
+func firstSentence(desc string) string {
+	desc = strings.TrimSpace(desc)
+	desc = strings.NewReplacer("**", "", "__", "", "`", "", "#", "").Replace(desc)
+	desc = strings.TrimSpace(desc)
+	if i := strings.Index(desc, "\n"); i >= 0 {
+		desc = desc[:i]
+	}
+	for _, sep := range []string{". ", "! ", "? "} {
+		if i := strings.Index(desc, sep); i >= 0 {
+			desc = desc[:i+1]
+		}
+	}
+	desc = strings.TrimSpace(desc)
+	if utf8.RuneCountInString(desc) > maxTooltipRunes {
+		runes := []rune(desc)
+		desc = string(runes[:maxTooltipRunes-3]) + "..."
+	}
+	return desc
+}
+
+func mermaidText(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s)
+}
+
+// GenerateTreeMermaid renders the tree as a Mermaid flowchart or mindmap
+// depending on opts.Style. Descriptions are left out: Mermaid tooltips need
+// click directives, which the default strict security level disables.
+func GenerateTreeMermaid(root *Node, opts config.TreeDiagramConfig) string {
+	tree := buildDiagramTree(root, opts)
+	var md strings.Builder
+
+	if opts.Style == "mindmap" {
+		md.WriteString("mindmap\n")
+		var walk func(d *diagramNode, depth int)
+		walk = func(d *diagramNode, depth int) {
+			indent := strings.Repeat("  ", depth+1)
+			if depth == 0 {
+				md.WriteString(fmt.Sprintf("%s%s((\"%s\"))\n", indent, d.id, mermaidText(d.label)))
+			} else {
+				md.WriteString(fmt.Sprintf("%s%s[\"%s\"]\n", indent, d.id, mermaidText(d.label)))
+			}
+			for _, child := range d.children {
+				walk(child, depth+1)
+			}
+		}
+		walk(tree, 0)
+		return md.String()
+	}
+
+	md.WriteString("flowchart LR\n")
+	var walk func(d *diagramNode)
+	walk = func(d *diagramNode) {
+		if d.isDir {
+			md.WriteString(fmt.Sprintf("    %s[\"%s\"]\n", d.id, mermaidText(d.label)))
+		} else {
+			md.WriteString(fmt.Sprintf("    %s(\"%s\")\n", d.id, mermaidText(d.label)))
+		}
+		for _, child := range d.children {
+			walk(child)
+			md.WriteString(fmt.Sprintf("    %s --> %s\n", d.id, child.id))
+		}
+	}
+	walk(tree)
+	return md.String()
+}
+
+// GenerateTreeDot renders the tree in Graphviz DOT format, with the first
+// sentence of each description as the node tooltip.
+func GenerateTreeDot(root *Node, opts config.TreeDiagramConfig) string {
+	tree := buildDiagramTree(root, opts)
+	var dot strings.Builder
+	dot.WriteString("digraph tree {\n")
+	dot.WriteString("    rankdir=LR;\n")
+	dot.WriteString("    node [fontname=\"Helvetica\"];\n")
+
+	var walk func(d *diagramNode)
+	walk = func(d *diagramNode) {
+		shape := "note"
+		if d.isDir {
+			shape = "folder"
+		}
+		dot.WriteString(fmt.Sprintf("    %s [shape=%s, label=%s", d.id, shape, strconv.Quote(d.label)))
+		if d.tooltip != "" {
+			dot.WriteString(fmt.Sprintf(", tooltip=%s", strconv.Quote(d.tooltip)))
+		}
+		dot.WriteString("];\n")
+		for _, child := range d.children {
+			walk(child)
+			dot.WriteString(fmt.Sprintf("    %s -> %s;\n", d.id, child.id))
+		}
+	}
+	walk(tree)
+	dot.WriteString("}\n")
+	return dot.String()
+}
@@ -5,12 +5,42 @@ import (
 )
 
 type Node struct {
-	Path        string  `json:"path"`
-	Name        string  `json:"name"`
-	Type        string  `json:"type"`
-	Content     string  `json:"content,omitempty"`
-	Description string  `json:"description,omitempty"`
-	Children    []*Node `json:"children,omitempty"`
+	Path        string    `json:"path"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Content     string    `json:"content,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Children    []*Node   `json:"children,omitempty"`
+	Metadata    *Metadata `json:"metadata,omitempty"`
+	History     *History  `json:"history,omitempty"`
+
+	// ChangeStatus is set by --since analyses: added, modified, renamed, deleted or unchanged
+	ChangeStatus string `json:"changeStatus,omitempty"`
+	// DuplicateOf is the path of the file with identical content whose description this file shares
+	DuplicateOf string `json:"duplicateOf,omitempty"`
+	// Similar lists the files whose content is close to this file's, the most similar first
+	Similar []SimilarFile `json:"similar,omitempty"`
+	// Secrets lists the secrets redacted from the content sent for analysis
+	Secrets []SecretFinding `json:"secrets,omitempty"`
+	// PII counts the personal data redacted from the extracted text, by category
+	PII map[string]int `json:"pii,omitempty"`
+	// Security lists the possible vulnerabilities found by the security profile, the most severe first
+	Security []SecurityFinding `json:"security,omitempty"`
+	// License is the SPDX expression declared by a file (license text or SPDX
+	// header), or the license governing a folder, from its own license files or
+	// else inherited from its parent
+	License string `json:"license,omitempty"`
+	// Licenses lists the licenses declared below a folder
+	Licenses []string `json:"licenses,omitempty"`
+	// Sampled is set when the file was larger than maxFileSize and only a
+	// sample of its content (head, middle windows and tail) was analyzed
+	Sampled bool `json:"sampled,omitempty"`
+
+	// sampleLines maps the lines of the sampled content to the lines of the file
+	sampleLines []int
+	// text is the text extracted from a document by the near-duplicate
+	// search, kept so that the analysis does not parse the file again
+	text string
 }
 
 type FileTypeStats struct {
@@ -54,10 +84,86 @@ type ChatResponse struct {
 type ImageRequest struct {
 	Image string `json:"image"`
 	// Model can be either a string (for Mistral-only shortcut) or an array of provider/model objects
-	Model interface{} `json:"model"`
+	Model   interface{}   `json:"model"`
 }
 
 type ImageResponse struct {
 	Analysis    string `json:"analysis"`
 	QueueLength int    `json:"queueLength"`
 }
+
+// AnalysisOutput is the document written to the JSON output file. Older
+// outputs contained only the bare Node tree; LoadAnalysisOutput accepts both.
+type AnalysisOutput struct {
+	Tree            *Node            `json:"tree"`
+	DependencyGraph *DependencyGraph `json:"dependencyGraph,omitempty"`
+	// Language code of the AI-generated descriptions (outputLanguage)
+	Language string `json:"language,omitempty"`
+	// Changes summarizes the last --since analysis merged into this output
+	Changes *ChangeSummary `json:"changes,omitempty"`
+	// Duplicates lists the groups of files with identical content
+	Duplicates []DuplicateGroup `json:"duplicates,omitempty"`
+	// NearDuplicates lists the clusters of files with mostly identical content
+	NearDuplicates []NearDuplicateCluster `json:"nearDuplicates,omitempty"`
+	// Inventory holds the statistics of a flat analysis
+	Inventory *Inventory `json:"inventory,omitempty"`
+	// Licenses lists the licenses declared in the tree and their incompatible combinations
+	Licenses *LicenseSummary `json:"licenses,omitempty"`
+}
+
+// ChangeSummary describes the git changes an incremental analysis covered.
+type ChangeSummary struct {
+	// Since is the revision given to --since and Commit the commit it resolved to
+	Since    string `json:"since"`
+	Commit   string `json:"commit"`
+	Added    int    `json:"added"`
+	Modified int    `json:"modified"`
+	Renamed  int    `json:"renamed"`
+	Deleted  int    `json:"deleted"`
+}
+
+// DependencyGraph is a package-level import graph. Internal node IDs are
+// slash-separated directories relative to the analyzed root ("." for the root).
+type DependencyGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+type GraphNode struct {
+	ID       string `json:"id"`
+	Language string `json:"language,omitempty"`
+	External bool   `json:"external,omitempty"`
+}
+
+type GraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int    `json:"count"`
+}
+
+// TargetLayout is the recommended folder structure returned by the
+// architecture step. Paths are relative to the analyzed root.
+type TargetLayout struct {
+	Folders []LayoutFolder `json:"folders"`
+}
+
+type LayoutFolder struct {
+	Path        string `json:"path"`
+	Description string `json:"description,omitempty"`
+	// Contents lists existing files or folders to move into this folder
+	Contents []string `json:"contents,omitempty"`
+}
+
+// MovePlan is the set of filesystem operations turning the current tree into
+// a TargetLayout. Paths are relative to Root.
+type MovePlan struct {
+	Root       string   `json:"root"`
+	CreateDirs []string `json:"createDirs"`
+	Moves      []Move   `json:"moves"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+type Move struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
@@ -33,7 +33,7 @@ func (a *App) PerformCountAnalysis(targetDir string) error {
 		return fmt.Errorf("error performing count analysis: %w", err)
 	}
 
-	estimationContent := analyzer.GenerateEstimationMarkdown(estimation)
+	estimationContent := analyzer.GenerateEstimationMarkdown(estimation, a.config.OutputLanguage)
 	estimationFile := filepath.Join(a.config.DefaultOutputDir, a.config.EstimationFile)
 	err = os.WriteFile(estimationFile, []byte(estimationContent), 0644)
 	if err != nil {
@@ -49,7 +49,10 @@ func (a *App) PerformCountAnalysis(targetDir string) error {
 	return nil
 }
 
-func (a *App) PerformFullAnalysis(targetDir string) error {
+// PerformFullAnalysis analyzes targetDir and writes the outputs. With a git
+// revision in since, only the changes since that revision are analyzed and
+// merged into the existing JSON output.
+func (a *App) PerformFullAnalysis(targetDir, since string) error {
 	fmt.Println("🔍 Analyzing directory structure...")
 
 	var totalFiles, totalDirs int
@@ -101,35 +104,96 @@ func (a *App) PerformFullAnalysis(targetDir string) error {
 		fmt.Printf("   %s: %d files (%s)\n", ext, count, status)
 	}
 
-	fmt.Printf("\n🚀 Starting file processing and AI analysis...\n")
-	fmt.Printf("   Note: This will make API calls to analyze each file's content\n")
-	fmt.Printf("   API endpoint: %s\n", a.config.APIBaseURL)
+	flat := analyzer.IsFlatMode(a.config.Mode)
+	if flat {
+		fmt.Printf("\n🚀 Starting flat analysis (local statistics only, no API calls)...\n")
+	} else {
+		fmt.Printf("\n🚀 Starting file processing and AI analysis...\n")
+		fmt.Printf("   Note: This will make API calls to analyze each file's content\n")
+		fmt.Printf("   API endpoint: %s\n", a.config.APIBaseURL)
+	}
+	security := analyzer.IsSecurityProfile(a.config.AnalysisProfile)
+	if security && flat {
+		fmt.Printf("   🛡️  Security profile: files are checked with local heuristics only\n")
+	} else if security {
+		fmt.Printf("   🛡️  Security profile: files are reviewed for vulnerabilities\n")
+	}
 
-	rootNode, err := a.analyzer.PerformFullAnalysis(targetDir, a.config.Mode)
+	outputFile := filepath.Join(a.config.DefaultOutputDir, a.config.JSONOutputFile)
+	var rootNode *analyzer.Node
+	var changes *analyzer.ChangeSummary
+	if flat {
+		if since != "" {
+			fmt.Printf("⚠️  --since is ignored in flat mode, the whole tree is analyzed locally\n")
+		}
+		rootNode, err = a.analyzer.PerformFlatAnalysis(targetDir)
+	} else if since != "" {
+		var previous *analyzer.Node
+		if prev, err := analyzer.LoadAnalysisOutput(outputFile); err == nil {
+			fmt.Printf("   Merging into the previous analysis in %s\n", outputFile)
+			previous = prev.Tree
+		} else if !os.IsNotExist(err) {
+			fmt.Printf("⚠️  Could not read the previous analysis, unchanged files will have no description: %v\n", err)
+		} else {
+			fmt.Printf("   No previous analysis in %s, unchanged files will have no description\n", outputFile)
+		}
+		rootNode, changes, err = a.analyzer.PerformChangedAnalysis(targetDir, a.config.Mode, since, previous)
+	} else {
+		rootNode, err = a.analyzer.PerformFullAnalysis(targetDir, a.config.Mode)
+	}
 	if err != nil {
 		return fmt.Errorf("error performing full analysis: %w", err)
 	}
 
-	fmt.Printf("\n\n✅ File processing and AI analysis complete!\n\n")
+	if flat {
+		fmt.Printf("\n\n✅ Flat analysis complete!\n\n")
+	} else {
+		fmt.Printf("\n\n✅ File processing and AI analysis complete!\n\n")
+	}
+
+	output := &analyzer.AnalysisOutput{Tree: rootNode, Language: analyzer.LanguageCode(a.config.OutputLanguage), Changes: changes}
+	output.Duplicates = analyzer.FindDuplicates(rootNode)
+	output.NearDuplicates = analyzer.FindNearDuplicates(rootNode)
+	output.Licenses = analyzer.SummarizeLicenses(rootNode)
+	if flat {
+		output.Inventory = analyzer.BuildInventory(rootNode, a.config.Inventory.Largest)
+	}
 
-	jsonOutput, err := json.MarshalIndent(rootNode, "", "  ")
+	fmt.Println("🕸️  Building dependency graph...")
+	graph, err := a.analyzer.BuildDependencyGraph(targetDir)
+	if err != nil {
+		fmt.Printf("⚠️  Could not build dependency graph: %v\n", err)
+	} else {
+		output.DependencyGraph = graph
+		fmt.Printf("   %d packages, %d import edges\n\n", len(graph.Nodes), len(graph.Edges))
+	}
+
+	jsonOutput, err := json.MarshalIndent(output, "", "  ")
 	if err != nil {
 		return fmt.Errorf("error marshalling to json: %w", err)
 	}
 
-	outputFile := filepath.Join(a.config.DefaultOutputDir, a.config.JSONOutputFile)
 	err = os.WriteFile(outputFile, jsonOutput, 0644)
 	if err != nil {
 		return fmt.Errorf("error writing json to file: %w", err)
 	}
 
-	markdownOutput := analyzer.GenerateMarkdownOutput(rootNode)
+	markdownOutput := analyzer.GenerateMarkdownOutput(output, a.markdownOptions())
 	markdownFile := filepath.Join(a.config.DefaultOutputDir, a.config.MarkdownOutputFile)
 	err = os.WriteFile(markdownFile, []byte(markdownOutput), 0644)
 	if err != nil {
 		return fmt.Errorf("error writing markdown to file: %w", err)
 	}
 
+	var securityFile string
+	if security && a.config.SecurityOutputFile != "" {
+		securityFile = filepath.Join(a.config.DefaultOutputDir, a.config.SecurityOutputFile)
+		report := analyzer.GenerateSecurityReport(rootNode, output.Language)
+		if err := os.WriteFile(securityFile, []byte(report), 0644); err != nil {
+			return fmt.Errorf("error writing security report: %w", err)
+		}
+	}
+
 	jsonFileInfo, err := os.Stat(outputFile)
 	if err != nil {
 		return fmt.Errorf("error getting json file info: %w", err)
@@ -140,15 +204,73 @@ func (a *App) PerformFullAnalysis(targetDir string) error {
 		return fmt.Errorf("error getting markdown file info: %w", err)
 	}
 
+	var graphFiles []string
+	if graph != nil {
+		renderings := []struct {
+			name    string
+			content string
+		}{
+			{a.config.DependencyMermaidFile, analyzer.GenerateDependencyMermaid(graph)},
+			{a.config.DependencyDotFile, analyzer.GenerateDependencyDot(graph)},
+		}
+		for _, r := range renderings {
+			if r.name == "" {
+				continue
+			}
+			graphFile := filepath.Join(a.config.DefaultOutputDir, r.name)
+			if err := os.WriteFile(graphFile, []byte(r.content), 0644); err != nil {
+				return fmt.Errorf("error writing dependency graph to file: %w", err)
+			}
+			graphFiles = append(graphFiles, graphFile)
+		}
+	}
+
+	var treeDotFile string
+	if a.config.TreeDotFile != "" {
+		treeDotFile = filepath.Join(a.config.DefaultOutputDir, a.config.TreeDotFile)
+		if err := os.WriteFile(treeDotFile, []byte(analyzer.GenerateTreeDot(rootNode, a.config.TreeDiagram)), 0644); err != nil {
+			return fmt.Errorf("error writing tree diagram to file: %w", err)
+		}
+	}
+
+	// A report.md left in the output directory may describe another tree: the
+	// architecture step adds the report it generates
+	htmlFile, err := a.writeHTMLReport(rootNode, "")
+	if err != nil {
+		return err
+	}
+
 	fmt.Printf("JSON output written to %s (size: %d bytes)\n", outputFile, jsonFileInfo.Size())
 	fmt.Printf("Markdown output written to %s (size: %d bytes)\n", markdownFile, markdownFileInfo.Size())
+	for _, f := range graphFiles {
+		fmt.Printf("Dependency graph written to %s\n", f)
+	}
+	if treeDotFile != "" {
+		fmt.Printf("Tree diagram written to %s\n", treeDotFile)
+	}
+	if htmlFile != "" {
+		fmt.Printf("HTML report written to %s\n", htmlFile)
+	}
+	if securityFile != "" {
+		fmt.Printf("Security report written to %s\n", securityFile)
+	}
 
 	fmt.Println("\n=== Process Completed Successfully ===")
 	fmt.Printf("✓ File tree processed\n")
-	fmt.Printf("✓ AI analysis completed for all files\n")
-	fmt.Printf("✓ AI analysis completed for all folders\n")
+	if flat {
+		fmt.Printf("✓ Inventory computed (%d files, %d folders)\n", output.Inventory.Files, output.Inventory.Folders)
+	} else {
+		fmt.Printf("✓ AI analysis completed for all files\n")
+		fmt.Printf("✓ AI analysis completed for all folders\n")
+	}
 	fmt.Printf("✓ JSON output generated: %s (%d bytes)\n", outputFile, jsonFileInfo.Size())
 	fmt.Printf("✓ Markdown output generated: %s (%d bytes)\n", markdownFile, markdownFileInfo.Size())
+	if graph != nil {
+		fmt.Printf("✓ Dependency graph extracted (%d packages, %d edges)\n", len(graph.Nodes), len(graph.Edges))
+	}
+	if securityFile != "" {
+		fmt.Printf("✓ Security report generated: %s (%d findings)\n", securityFile, len(analyzer.FindSecurityFindings(rootNode)))
+	}
 
 	fmt.Print("\nPress Enter to exit...")
 	reader := bufio.NewReader(os.Stdin)
@@ -161,45 +283,48 @@ func (a *App) PerformArchitectureAnalysis() error {
 	fmt.Println("🏗️  Starting architectural analysis...")
 
 	jsonFile := filepath.Join(a.config.DefaultOutputDir, a.config.JSONOutputFile)
-	markdownFile := filepath.Join(a.config.DefaultOutputDir, a.config.MarkdownOutputFile)
 
 	if _, err := os.Stat(jsonFile); os.IsNotExist(err) {
 		return fmt.Errorf("%s not found. Please run the tool without flags first to generate the analysis files", jsonFile)
 	}
-	if _, err := os.Stat(markdownFile); os.IsNotExist(err) {
-		return fmt.Errorf("%s not found. Please run the tool without flags first to generate the analysis files", markdownFile)
-	}
 
-	jsonContent, err := os.ReadFile(jsonFile)
+	output, err := analyzer.LoadAnalysisOutput(jsonFile)
 	if err != nil {
 		return fmt.Errorf("error reading %s: %v", jsonFile, err)
 	}
 
-	mdContent, err := os.ReadFile(markdownFile)
+	var analyses []*analyzer.ArchitectureReport
+
+	aiClient, err := analyzer.NewAIClient(a.config)
 	if err != nil {
-		return fmt.Errorf("error reading %s: %v", markdownFile, err)
+		return err
 	}
+	projectContext, err := analyzer.LoadProjectContext(a.config, output.Tree.Path)
+	if err != nil {
+		return err
+	}
+	aiClient.SetProjectContext(projectContext)
+	signals := analyzer.ArchitectureSignals{
+		Hotspots:       analyzer.FormatHotspots(output.Tree, a.config.History.Hotspots),
+		NearDuplicates: analyzer.FormatNearDuplicates(output.Tree, a.config.NearDuplicates.Clusters),
+	}
+	if signals.Hotspots != "" {
+		fmt.Printf("🔥 Including %d git history hotspots\n", strings.Count(signals.Hotspots, "\n")+1)
+	}
+	if signals.NearDuplicates != "" {
+		fmt.Printf("🧩 Including %d near-duplicate clusters\n", strings.Count(signals.NearDuplicates, "\n")+1)
+	}
+	maxChunkTokens := aiClient.ArchitectureContentTokens(signals)
 
-	combinedContent := fmt.Sprintf("JSON Structure Data:\n%s\n\nMarkdown Tree Visualization:\n%s", string(jsonContent), string(mdContent))
-
-	fmt.Printf("📊 Total content size: %d characters\n", len(combinedContent))
-
-	var analyses []string
-	const maxChunkSize = 50000
-
-	aiClient := analyzer.NewAIClient(a.config)
-
-	if len(combinedContent) > maxChunkSize {
-		fmt.Printf("📋 Content exceeds %d characters, splitting into chunks...\n", maxChunkSize)
+	chunks := analyzer.BuildArchitectureChunks(output, maxChunkTokens, a.config.ArchitectureDetail)
+	totalTokens := 0
+	for _, chunk := range chunks {
+		totalTokens += analyzer.EstimateTokens(chunk)
+	}
+	fmt.Printf("📊 Total content size: ~%d tokens\n", totalTokens)
 
-		chunks := make([]string, 0)
-		for i := 0; i < len(combinedContent); i += maxChunkSize {
-			end := i + maxChunkSize
-			if end > len(combinedContent) {
-				end = len(combinedContent)
-			}
-			chunks = append(chunks, combinedContent[i:end])
-		}
+	if len(chunks) > 1 {
+		fmt.Printf("📋 Content exceeds the ~%d-token budget of the architecture model, split into %d subtree chunks\n", maxChunkTokens, len(chunks))
 
 		fmt.Printf("🔄 Processing %d chunks...\n", len(chunks))
 
@@ -210,21 +335,21 @@ func (a *App) PerformArchitectureAnalysis() error {
 		}
 		cj := make(chan chunkJob, len(chunks))
 		cres := make(chan struct {
-			idx int
-			txt string
-			err error
+			idx    int
+			report *analyzer.ArchitectureReport
+			err    error
 		}, len(chunks))
 
 		for w := 0; w < archiConcurrency; w++ {
 			go func() {
 				for job := range cj {
 					fmt.Printf("🔍 Analyzing chunk %d/%d...\n", job.idx+1, len(chunks))
-					analysis, err := aiClient.AnalyzeArchitecture(job.chunk, fmt.Sprintf("chunk_%d.combined", job.idx+1))
+					analysis, err := aiClient.AnalyzeArchitecture(job.chunk, fmt.Sprintf("chunk_%d.combined", job.idx+1), signals)
 					cres <- struct {
-						idx int
-						txt string
-						err error
-					}{idx: job.idx, txt: analysis, err: err}
+						idx    int
+						report *analyzer.ArchitectureReport
+						err    error
+					}{idx: job.idx, report: analysis, err: err}
 				}
 			}()
 		}
@@ -234,13 +359,13 @@ func (a *App) PerformArchitectureAnalysis() error {
 		}
 		close(cj)
 
-		analyses = make([]string, len(chunks))
+		analyses = make([]*analyzer.ArchitectureReport, len(chunks))
 		for i := 0; i < len(chunks); i++ {
 			r := <-cres
 			if r.err != nil {
 				return fmt.Errorf("error analyzing chunk %d: %v", r.idx+1, r.err)
 			}
-			analyses[r.idx] = r.txt
+			analyses[r.idx] = r.report
 		}
 
 		reduced := analyses
@@ -248,24 +373,28 @@ func (a *App) PerformArchitectureAnalysis() error {
 		for len(reduced) > 1 {
 			fmt.Printf("🔁 Reduction round %d: %d analyses to reduce...\n", round, len(reduced))
 
-			groups := make([][]string, 0)
-			var currentGroup []string
+			groups := make([][]*analyzer.ArchitectureReport, 0)
+			var currentGroup []*analyzer.ArchitectureReport
 			currentLen := 0
 
-			for _, aText := range reduced {
-				aLen := len(aText)
+			for _, aReport := range reduced {
+				aJSON, err := json.Marshal(aReport)
+				if err != nil {
+					return fmt.Errorf("error marshalling analysis: %v", err)
+				}
+				aLen := analyzer.EstimateTokens(string(aJSON))
 				if len(currentGroup) == 0 {
-					currentGroup = []string{aText}
+					currentGroup = []*analyzer.ArchitectureReport{aReport}
 					currentLen = aLen + 2 // separator
 					continue
 				}
 
-				if currentLen+aLen+2 <= maxChunkSize {
-					currentGroup = append(currentGroup, aText)
+				if currentLen+aLen+2 <= maxChunkTokens {
+					currentGroup = append(currentGroup, aReport)
 					currentLen += aLen + 2
 				} else {
 					groups = append(groups, currentGroup)
-					currentGroup = []string{aText}
+					currentGroup = []*analyzer.ArchitectureReport{aReport}
 					currentLen = aLen + 2
 				}
 			}
@@ -277,14 +406,14 @@ func (a *App) PerformArchitectureAnalysis() error {
 
 			concurrency := a.config.Concurrency.ReportChunking
 			type result struct {
-				idx int
-				txt string
-				err error
+				idx    int
+				report *analyzer.ArchitectureReport
+				err    error
 			}
 
 			jobs := make(chan struct {
 				idx int
-				grp []string
+				grp []*analyzer.ArchitectureReport
 			}, len(groups))
 			results := make(chan result, len(groups))
 
@@ -294,7 +423,7 @@ func (a *App) PerformArchitectureAnalysis() error {
 					for job := range jobs {
 						fmt.Printf("   ➤ Combining group %d/%d (items: %d)...\n", job.idx+1, len(groups), len(job.grp))
 						combined, err := aiClient.CombineArchitecturalAnalyses(job.grp)
-						results <- result{idx: job.idx, txt: combined, err: err}
+						results <- result{idx: job.idx, report: combined, err: err}
 					}
 				}()
 			}
@@ -303,19 +432,19 @@ func (a *App) PerformArchitectureAnalysis() error {
 			for gi, grp := range groups {
 				jobs <- struct {
 					idx int
-					grp []string
+					grp []*analyzer.ArchitectureReport
 				}{idx: gi, grp: grp}
 			}
 			close(jobs)
 
 			// collect
-			nextRound := make([]string, len(groups))
+			nextRound := make([]*analyzer.ArchitectureReport, len(groups))
 			for i := 0; i < len(groups); i++ {
 				res := <-results
 				if res.err != nil {
 					return fmt.Errorf("error combining group %d analyses: %v", res.idx+1, res.err)
 				}
-				nextRound[res.idx] = res.txt
+				nextRound[res.idx] = res.report
 			}
 
 			reduced = nextRound
@@ -329,36 +458,106 @@ func (a *App) PerformArchitectureAnalysis() error {
 
 		finalAnalysis := reduced[0]
 
-		err = os.WriteFile(filepath.Join(a.config.DefaultOutputDir, a.config.ReportOutputFile), []byte(finalAnalysis), 0644)
-		if err != nil {
-			return fmt.Errorf("error writing report: %v", err)
+		if err := a.writeArchitectureReport(finalAnalysis); err != nil {
+			return err
 		}
 
 	} else {
 		fmt.Println("📋 Content size is manageable, processing as single analysis...")
 
-		analysis, err := aiClient.AnalyzeArchitecture(combinedContent, "output.json and output.md")
+		analysis, err := aiClient.AnalyzeArchitecture(chunks[0], a.config.JSONOutputFile, signals)
 		if err != nil {
 			return fmt.Errorf("error analyzing architecture: %v", err)
 		}
 
-		err = os.WriteFile(filepath.Join(a.config.DefaultOutputDir, a.config.ReportOutputFile), []byte(analysis), 0644)
-		if err != nil {
-			return fmt.Errorf("error writing report: %v", err)
+		if err := a.writeArchitectureReport(analysis); err != nil {
+			return err
 		}
 	}
 
 	fmt.Println("✅ Architectural analysis complete!")
 	fmt.Printf("📄 Report saved to: %s\n", filepath.Join(a.config.DefaultOutputDir, a.config.ReportOutputFile))
+	if a.config.ReportJSONFile != "" {
+		fmt.Printf("📄 Structured report saved to: %s\n", filepath.Join(a.config.DefaultOutputDir, a.config.ReportJSONFile))
+	}
+
+	if a.config.LayoutOutputFile != "" {
+		if err := a.writeTargetLayout(aiClient, output.Tree); err != nil {
+			fmt.Printf("⚠️  Could not generate the recommended folder structure: %v\n", err)
+		}
+	}
+
+	report, err := os.ReadFile(filepath.Join(a.config.DefaultOutputDir, a.config.ReportOutputFile))
+	if err != nil {
+		return fmt.Errorf("error reading architecture report: %w", err)
+	}
+	htmlFile, err := a.writeHTMLReport(output.Tree, string(report))
+	if err != nil {
+		return err
+	}
+	if htmlFile != "" {
+		fmt.Printf("🌐 HTML report updated: %s\n", htmlFile)
+	}
 
 	return nil
 }
 
+// writeArchitectureReport writes the structured report as JSON and the
+// markdown report rendered from it.
+func (a *App) writeArchitectureReport(report *analyzer.ArchitectureReport) error {
+	data, err := analyzer.MarshalArchitectureReport(report)
+	if err != nil {
+		return fmt.Errorf("error marshalling report: %v", err)
+	}
+
+	if a.config.ReportJSONFile != "" {
+		err = os.WriteFile(filepath.Join(a.config.DefaultOutputDir, a.config.ReportJSONFile), data, 0644)
+		if err != nil {
+			return fmt.Errorf("error writing report: %v", err)
+		}
+	}
+
+	err = os.WriteFile(filepath.Join(a.config.DefaultOutputDir, a.config.ReportOutputFile), []byte(analyzer.RenderArchitectureMarkdown(report, a.config.OutputLanguage)), 0644)
+	if err != nil {
+		return fmt.Errorf("error writing report: %v", err)
+	}
+	return nil
+}
+
+func (a *App) markdownOptions() analyzer.MarkdownOptions {
+	return analyzer.MarkdownOptions{
+		Style:    a.config.MarkdownStyle,
+		Diagram:  a.config.TreeDiagram,
+		Language: a.config.OutputLanguage,
+		Hotspots: a.config.History.Hotspots,
+	}
+}
+
+// writeHTMLReport renders the interactive HTML report, embedding the
+// markdown architecture report when one is given. It returns the written
+// path, or "" when the HTML output is disabled.
+func (a *App) writeHTMLReport(rootNode *analyzer.Node, report string) (string, error) {
+	if a.config.HTMLOutputFile == "" {
+		return "", nil
+	}
+
+	page, err := analyzer.GenerateHTMLReport(rootNode, report)
+	if err != nil {
+		return "", err
+	}
+
+	htmlFile := filepath.Join(a.config.DefaultOutputDir, a.config.HTMLOutputFile)
+	if err := os.WriteFile(htmlFile, []byte(page), 0644); err != nil {
+		return "", fmt.Errorf("error writing HTML report: %w", err)
+	}
+	return htmlFile, nil
+}
+
 func (a *App) isExtractableFile(ext string) bool {
 	extractableExts := []string{
 		".txt", ".md", ".go", ".js", ".py", ".java", ".c", ".cpp", ".h", ".hpp",
 		".css", ".html", ".xml", ".json", ".yaml", ".yml", ".toml", ".ini",
-		".cfg", ".conf", ".docx", ".xlsx", ".xls", ".pdf",
+		".cfg", ".conf", ".log", ".csv", ".tsv", ".jsonl", ".docx", ".xlsx", ".xls", ".pdf", ".eml",
 	}
 
 	for _, e := range extractableExts {
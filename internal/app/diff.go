@@ -0,0 +1,80 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"archi/internal/analyzer"
+)
+
+// DiffOptions controls the comparison of two analyses.
+type DiffOptions struct {
+	// OldFile and NewFile are the JSON outputs of the two analyses.
+	OldFile string
+	NewFile string
+	// Summarize asks the architecture model to summarize the evolution.
+	Summarize bool
+}
+
+// DiffAnalyses compares two JSON outputs and writes the comparison as
+// markdown and JSON.
+func (a *App) DiffAnalyses(opts DiffOptions) error {
+	var trees [2]*analyzer.Node
+	for i, file := range []string{opts.OldFile, opts.NewFile} {
+		output, err := analyzer.LoadAnalysisOutput(file)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", file, err)
+		}
+		if output.Tree == nil {
+			return fmt.Errorf("%s contains no analysis tree", file)
+		}
+		trees[i] = output.Tree
+	}
+
+	fmt.Printf("🔀 Comparing %s with %s...\n", opts.OldFile, opts.NewFile)
+	diff := analyzer.DiffSnapshots(trees[0], trees[1])
+	fmt.Printf("   %d added, %d removed, %d moved, %d modified, %d changed descriptions, %d folders resized\n",
+		len(diff.Added), len(diff.Removed), len(diff.Moved), len(diff.Modified), len(diff.Descriptions), len(diff.Folders))
+
+	if opts.Summarize {
+		fmt.Println("🤖 Summarizing the evolution...")
+		client, err := analyzer.NewAIClient(a.config)
+		if err != nil {
+			return err
+		}
+		projectContext, err := analyzer.LoadProjectContext(a.config, trees[1].Path)
+		if err != nil {
+			return err
+		}
+		client.SetProjectContext(projectContext)
+		summary, err := client.SummarizeEvolution(analyzer.GenerateSnapshotDiffMarkdown(diff, ""))
+		if err != nil {
+			fmt.Printf("⚠️  Could not summarize the evolution: %v\n", err)
+		} else {
+			diff.Summary = summary
+		}
+	}
+
+	if a.config.DiffJSONFile != "" {
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshalling comparison: %w", err)
+		}
+		jsonFile := filepath.Join(a.config.DefaultOutputDir, a.config.DiffJSONFile)
+		if err := os.WriteFile(jsonFile, data, 0644); err != nil {
+			return fmt.Errorf("error writing comparison: %w", err)
+		}
+		fmt.Printf("📄 Comparison data saved to: %s\n", jsonFile)
+	}
+	if a.config.DiffOutputFile != "" {
+		markdownFile := filepath.Join(a.config.DefaultOutputDir, a.config.DiffOutputFile)
+		markdown := analyzer.GenerateSnapshotDiffMarkdown(diff, a.config.OutputLanguage)
+		if err := os.WriteFile(markdownFile, []byte(markdown), 0644); err != nil {
+			return fmt.Errorf("error writing comparison: %w", err)
+		}
+		fmt.Printf("📄 Comparison saved to: %s\n", markdownFile)
+	}
+	return nil
+}
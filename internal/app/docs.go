@@ -0,0 +1,116 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"archi/internal/analyzer"
+)
+
+// DocsOptions controls how folder READMEs are written.
+type DocsOptions struct {
+	// MirrorDir, when set, receives the READMEs in a directory tree mirroring
+	// the analyzed one instead of writing into the analyzed folders.
+	MirrorDir string
+	// DryRun prints the diff of every README that would change without writing.
+	DryRun bool
+}
+
+// WriteFolderReadmes writes a README.md into each analyzed folder from the
+// JSON output. Existing READMEs are only replaced when archi generated them.
+func (a *App) WriteFolderReadmes(opts DocsOptions) error {
+	jsonFile := filepath.Join(a.config.DefaultOutputDir, a.config.JSONOutputFile)
+	if _, err := os.Stat(jsonFile); os.IsNotExist(err) {
+		return fmt.Errorf("%s not found. Please run the tool without flags first to generate the analysis files", jsonFile)
+	}
+
+	output, err := analyzer.LoadAnalysisOutput(jsonFile)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", jsonFile, err)
+	}
+	root := output.Tree
+
+	if opts.DryRun {
+		fmt.Println("📝 Dry run: no files will be written")
+	}
+
+	var written, unchanged, skipped int
+	var visit func(n *analyzer.Node) error
+	visit = func(n *analyzer.Node) error {
+		if n.Type != "directory" {
+			return nil
+		}
+
+		dir := n.Path
+		if opts.MirrorDir != "" {
+			rel, err := filepath.Rel(root.Path, n.Path)
+			if err != nil {
+				return fmt.Errorf("error resolving %s: %w", n.Path, err)
+			}
+			dir = filepath.Join(opts.MirrorDir, rel)
+		} else if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			fmt.Printf("⚠️  Skipping %s: folder not found\n", dir)
+			skipped++
+			return nil
+		}
+
+		content := analyzer.GenerateFolderReadme(n)
+		target := filepath.Join(dir, "README.md")
+		var existing string
+		if found := analyzer.FindReadme(dir); found != "" {
+			data, err := os.ReadFile(found)
+			if err != nil {
+				return fmt.Errorf("error reading %s: %w", found, err)
+			}
+			if !analyzer.IsGeneratedReadme(string(data)) {
+				fmt.Printf("⏭️  Skipping %s: existing README was not generated by archi\n", found)
+				skipped++
+				return walkChildren(n, visit)
+			}
+			existing = string(data)
+			target = found
+		}
+
+		if existing == content {
+			unchanged++
+			return walkChildren(n, visit)
+		}
+
+		if opts.DryRun {
+			fmt.Print(analyzer.UnifiedDiff(target+" (current)", target, existing, content))
+			written++
+			return walkChildren(n, visit)
+		}
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("error creating %s: %w", dir, err)
+		}
+		if err := os.WriteFile(target, []byte(content), 0644); err != nil {
+			return fmt.Errorf("error writing %s: %w", target, err)
+		}
+		fmt.Printf("✓ %s\n", target)
+		written++
+		return walkChildren(n, visit)
+	}
+
+	if err := visit(root); err != nil {
+		return err
+	}
+
+	verb := "written"
+	if opts.DryRun {
+		verb = "to write"
+	}
+	fmt.Printf("\n📚 READMEs %s: %d, unchanged: %d, skipped: %d\n", verb, written, unchanged, skipped)
+	return nil
+}
+
+func walkChildren(n *analyzer.Node, visit func(*analyzer.Node) error) error {
+	for _, child := range n.Children {
+		if err := visit(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
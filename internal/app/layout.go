@@ -0,0 +1,119 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"archi/internal/analyzer"
+)
+
+// ApplyOptions controls "architecture apply".
+type ApplyOptions struct {
+	// Root overrides the project root recorded in the JSON output
+	Root string
+	// Execute performs the moves; otherwise the plan is only printed
+	Execute bool
+	// GitScript, when set, receives a "git mv" script instead of moving files
+	GitScript string
+}
+
+// writeTargetLayout asks the architecture model for the recommended folder
+// structure, based on the report just written, and saves it as JSON.
+func (a *App) writeTargetLayout(aiClient *analyzer.AIClient, root *analyzer.Node) error {
+	report, err := os.ReadFile(filepath.Join(a.config.DefaultOutputDir, a.config.ReportOutputFile))
+	if err != nil {
+		return err
+	}
+
+	paths := analyzer.TreePaths(root)
+
+	fmt.Println("🗺️  Generating the recommended folder structure...")
+	layout, err := aiClient.GenerateTargetLayout(paths, string(report))
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(layout, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling layout: %w", err)
+	}
+	layoutFile := filepath.Join(a.config.DefaultOutputDir, a.config.LayoutOutputFile)
+	if err := os.WriteFile(layoutFile, data, 0644); err != nil {
+		return fmt.Errorf("error writing layout: %w", err)
+	}
+	fmt.Printf("🗺️  Recommended folder structure saved to: %s (%d folders)\n", layoutFile, len(layout.Folders))
+	return nil
+}
+
+// ApplyArchitectureLayout computes the move plan from the analyzed tree to
+// the recommended layout and prints it, writes it as a git script, or applies
+// it with rollback on failure.
+func (a *App) ApplyArchitectureLayout(opts ApplyOptions) error {
+	if a.config.LayoutOutputFile == "" {
+		return fmt.Errorf("layoutOutputFile is disabled: set it and run the architecture command to generate the recommended layout")
+	}
+	jsonFile := filepath.Join(a.config.DefaultOutputDir, a.config.JSONOutputFile)
+	layoutFile := filepath.Join(a.config.DefaultOutputDir, a.config.LayoutOutputFile)
+
+	if _, err := os.Stat(jsonFile); os.IsNotExist(err) {
+		return fmt.Errorf("%s not found. Please run the tool without flags first to generate the analysis files", jsonFile)
+	}
+	if _, err := os.Stat(layoutFile); os.IsNotExist(err) {
+		return fmt.Errorf("%s not found. Please run the architecture command first to generate the recommended layout", layoutFile)
+	}
+
+	output, err := analyzer.LoadAnalysisOutput(jsonFile)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", jsonFile, err)
+	}
+
+	data, err := os.ReadFile(layoutFile)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", layoutFile, err)
+	}
+	var layout analyzer.TargetLayout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return fmt.Errorf("error decoding %s: %v", layoutFile, err)
+	}
+
+	plan := analyzer.ComputeMovePlan(output.Tree, &layout)
+	if opts.Root != "" {
+		plan.Root = opts.Root
+	}
+
+	fmt.Println("🏗️  Recommended folder structure move plan")
+	fmt.Println()
+	fmt.Print(analyzer.FormatMovePlan(plan))
+	fmt.Println()
+
+	if len(plan.CreateDirs) == 0 && len(plan.Moves) == 0 {
+		fmt.Println("✅ The project already matches the recommended structure")
+		return nil
+	}
+
+	if opts.GitScript != "" {
+		if err := os.WriteFile(opts.GitScript, []byte(analyzer.GenerateGitMoveScript(plan)), 0755); err != nil {
+			return fmt.Errorf("error writing git script: %w", err)
+		}
+		fmt.Printf("📜 git mv script written to %s (run it from %s)\n", opts.GitScript, plan.Root)
+		return nil
+	}
+
+	if !opts.Execute {
+		fmt.Println("ℹ️  Dry run: nothing was changed. Use --execute to apply, or --git-script to generate a git mv script.")
+		return nil
+	}
+
+	if info, err := os.Stat(plan.Root); err != nil || !info.IsDir() {
+		return fmt.Errorf("project root %s not found; use --root to point to it", plan.Root)
+	}
+
+	if err := analyzer.ApplyMovePlan(plan); err != nil {
+		return fmt.Errorf("error applying layout: %w", err)
+	}
+	fmt.Printf("✅ Applied: %d folders created, %d paths moved\n", len(plan.CreateDirs), len(plan.Moves))
+	fmt.Println("   Re-run the analysis to refresh the output files.")
+	return nil
+}
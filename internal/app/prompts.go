@@ -0,0 +1,54 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"archi/internal/analyzer"
+)
+
+// DumpPrompts writes the built-in prompt templates into dir, one
+// <name>.tmpl file per prompt, so they can be customized and loaded back with
+// promptDir. Existing files are kept unless force is set.
+func DumpPrompts(dir string, force bool) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating %s: %w", dir, err)
+	}
+
+	written, skipped := 0, 0
+	for _, name := range analyzer.PromptNames {
+		text, err := analyzer.BuiltinPrompt(name)
+		if err != nil {
+			return err
+		}
+		file := filepath.Join(dir, name+".tmpl")
+		if _, err := os.Stat(file); err == nil && !force {
+			fmt.Printf("⏭️  %s already exists (use --force to overwrite)\n", file)
+			skipped++
+			continue
+		}
+		if err := os.WriteFile(file, []byte(text), 0644); err != nil {
+			return fmt.Errorf("error writing %s: %w", file, err)
+		}
+		fmt.Printf("📝 %s\n", file)
+		written++
+	}
+
+	fmt.Printf("\n✅ %d prompt(s) written, %d skipped\n", written, skipped)
+	fmt.Printf("   Set promptDir: %q in config.yaml to use them\n", dir)
+	return nil
+}
+
+// ListPrompts prints where each prompt is loaded from with the current
+// configuration, failing on templates that do not parse.
+func (a *App) ListPrompts() error {
+	prompts, err := analyzer.LoadPrompts(a.config)
+	if err != nil {
+		return err
+	}
+	for _, name := range analyzer.PromptNames {
+		fmt.Printf("   %-13s %s\n", name, prompts.Source(name))
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -15,9 +17,39 @@ type Config struct {
 	JSONOutputFile            string        `mapstructure:"jsonOutputFile"`
 	MarkdownOutputFile        string        `mapstructure:"markdownOutputFile"`
 	ReportOutputFile          string        `mapstructure:"reportOutputFile"`
+	// Structured (JSON) architecture report written next to ReportOutputFile; empty disables it
+	ReportJSONFile            string        `mapstructure:"reportJSONFile"`
+	// Comparison written by "archi diff", as markdown and JSON (empty to disable either)
+	DiffOutputFile            string        `mapstructure:"diffOutputFile"`
+	DiffJSONFile              string        `mapstructure:"diffJSONFile"`
+	// Vulnerabilities ranked by severity, written by the security profile
+	SecurityOutputFile        string        `mapstructure:"securityOutputFile"`
 	EstimationFile            string        `mapstructure:"estimationFile"`
-	// Mode controls the analysis behavior: "full", "description-only", or "folder-only"
+	// Machine-readable recommended folder structure produced by the architecture step
+	LayoutOutputFile          string        `mapstructure:"layoutOutputFile"`
+	// Dependency graph renderings; an empty name disables the file
+	DependencyMermaidFile     string        `mapstructure:"dependencyMermaidFile"`
+	DependencyDotFile         string        `mapstructure:"dependencyDotFile"`
+	TreeDotFile               string        `mapstructure:"treeDotFile"`
+	// Self-contained interactive HTML report; an empty name disables it
+	HTMLOutputFile            string        `mapstructure:"htmlOutputFile"`
+	TreeDiagram               TreeDiagramConfig `mapstructure:"treeDiagram"`
+	// MarkdownStyle controls the markdown output: "tree" (ASCII tree) or "documentation" (sections with descriptions)
+	MarkdownStyle             string        `mapstructure:"markdownStyle"`
+	// ArchitectureDetail controls the tree sent to the architecture step: "minimal", "standard" or "full" (adds content excerpts)
+	ArchitectureDetail        string        `mapstructure:"architectureDetail"`
+	// OutputLanguage of the AI-generated text and of the markdown headings, e.g. "en" or "fr"
+	OutputLanguage            string        `mapstructure:"outputLanguage"`
+	// Project context (business domain, glossary, conventions) added to every prompt
+	Context                   string        `mapstructure:"context"`
+	// File holding the project context, relative to the analyzed directory (or the working directory)
+	ContextFile               string        `mapstructure:"contextFile"`
+	// Name of the optional per-folder context files, applying to the folder and its descendants
+	FolderContextFile         string        `mapstructure:"folderContextFile"`
+	// Mode controls the analysis behavior: "full", "description-only", "folder-only", or "flat" (local statistics, no AI calls)
 	Mode                      string        `mapstructure:"mode"`
+	// AnalysisProfile selects what files are analyzed for: "description" (what the file does) or "security" (vulnerabilities, written to SecurityOutputFile)
+	AnalysisProfile           string        `mapstructure:"analysisProfile"`
 	// Single-model (backward compatible). If set, must be a Mistral model when sent as string to the API
 	FileAnalysisModel         string        `mapstructure:"fileAnalysisModel"`
 	FolderAnalysisModel       string        `mapstructure:"folderAnalysisModel"`
@@ -33,6 +65,102 @@ type Config struct {
 	RequestDelay              time.Duration `mapstructure:"-"`
 	BatchSize                 int           `mapstructure:"batchSize"`
 	Concurrency               ConcurrencyConfig `mapstructure:"concurrency"`
+	Budget                    BudgetConfig  `mapstructure:"budget"`
+	Metadata                  MetadataConfig `mapstructure:"metadata"`
+	History                   HistoryConfig `mapstructure:"history"`
+	Duplicates                DuplicatesConfig `mapstructure:"duplicates"`
+	NearDuplicates            NearDuplicatesConfig `mapstructure:"nearDuplicates"`
+	Inventory                 InventoryConfig `mapstructure:"inventory"`
+	Secrets                   SecretsConfig `mapstructure:"secrets"`
+	PII                       PIIConfig     `mapstructure:"pii"`
+	Licenses                  LicensesConfig `mapstructure:"licenses"`
+	Sampling                  SamplingConfig `mapstructure:"sampling"`
+	// Prompt templates overriding the built-in ones, by prompt name (file, folder, image, architecture, combine, repair, layout, evolution, security)
+	Prompts                   map[string]string `mapstructure:"prompts"`
+	// Directory holding <name>.tmpl prompt templates; inline Prompts take precedence
+	PromptDir                 string        `mapstructure:"promptDir"`
+}
+
+// MetadataConfig controls the file metadata recorded per node and shown to the file prompt
+type MetadataConfig struct {
+	// Enabled records size, modification time, permissions, MIME type, line count, language and SHA-256
+	Enabled bool `mapstructure:"enabled" json:"enabled"`
+	// Git adds the last commit author and date when the analyzed directory is inside a git repository
+	Git bool `mapstructure:"git" json:"git"`
+	// GitMaxCommits bounds the history searched for last commits (0 = whole history)
+	GitMaxCommits int `mapstructure:"gitMaxCommits" json:"gitMaxCommits"`
+}
+
+// HistoryConfig controls the git history statistics recorded per node and the hotspots derived from them
+type HistoryConfig struct {
+	// Enabled mines the git history when the analyzed directory is inside a git repository
+	Enabled bool `mapstructure:"enabled" json:"enabled"`
+	// MaxCommits bounds the number of most recent commits read (0 = whole history)
+	MaxCommits int `mapstructure:"maxCommits" json:"maxCommits"`
+	// Hotspots is the number of hotspots listed in the markdown output and given to the architecture step
+	Hotspots int `mapstructure:"hotspots" json:"hotspots"`
+}
+
+// DuplicatesConfig controls the detection of files with identical content
+type DuplicatesConfig struct {
+	// Enabled analyzes each content once and shares the description between the copies (needs metadata)
+	Enabled bool `mapstructure:"enabled" json:"enabled"`
+	// MinSize is the size in bytes below which files are not reported as duplicates
+	MinSize int64 `mapstructure:"minSize" json:"minSize"`
+}
+
+// NearDuplicatesConfig controls the detection of files with mostly identical content
+type NearDuplicatesConfig struct {
+	// Enabled compares the extracted text of the files with MinHash signatures of word shingles
+	Enabled bool `mapstructure:"enabled" json:"enabled"`
+	// Threshold is the estimated similarity (0 to 1) above which two files are near duplicates
+	Threshold float64 `mapstructure:"threshold" json:"threshold"`
+	// MinWords leaves out files with fewer words, too short to compare meaningfully
+	MinWords int `mapstructure:"minWords" json:"minWords"`
+	// Clusters is the number of largest clusters given to the architecture step
+	Clusters int `mapstructure:"clusters" json:"clusters"`
+}
+
+// InventoryConfig controls the statistics of the flat mode
+type InventoryConfig struct {
+	// Largest is the number of largest files and folders listed
+	Largest int `mapstructure:"largest" json:"largest"`
+}
+
+// SecretsConfig controls the detection of credentials in file contents, which
+// are redacted before being sent for analysis or written to the output
+type SecretsConfig struct {
+	// Enabled detects and redacts secrets with the built-in rules and Rules
+	Enabled bool `mapstructure:"enabled" json:"enabled"`
+	// Entropy is the Shannon entropy in bits per character above which long random-looking strings are secrets (0 disables)
+	Entropy float64 `mapstructure:"entropy" json:"entropy"`
+	// Rules are custom secret patterns added to the built-in ones
+	Rules []SecretRule `mapstructure:"rules" json:"rules"`
+}
+
+// SecretRule is a custom secret pattern. When the regular expression has a
+// capture group, only the group is redacted.
+type SecretRule struct {
+	Name    string `mapstructure:"name" json:"name"`
+	Pattern string `mapstructure:"pattern" json:"pattern"`
+}
+
+// PIICategories are the kinds of personal data the PII filter can redact
+var PIICategories = []string{"email", "phone", "iban", "national-id", "name"}
+
+// PIIConfig controls the redaction of personal data from the text extracted
+// from files, before it is sent for analysis or written to the output
+type PIIConfig struct {
+	// Enabled redacts personal data from the files matching Extensions
+	Enabled bool `mapstructure:"enabled" json:"enabled"`
+	// Categories are the kinds of personal data redacted (email, phone, iban, national-id, name)
+	Categories []string `mapstructure:"categories" json:"categories"`
+	// Extensions are the file extensions filtered; empty filters every file
+	Extensions []string `mapstructure:"extensions" json:"extensions"`
+	// Names are the person names redacted, matched case-insensitively
+	Names []string `mapstructure:"names" json:"names"`
+	// NamesFile is a file with one more name per line
+	NamesFile string `mapstructure:"namesFile" json:"namesFile"`
 }
 
 type ConcurrencyConfig struct {
@@ -40,6 +168,49 @@ type ConcurrencyConfig struct {
 	ReportChunking int `mapstructure:"reportChunking" json:"reportChunking"`
 }
 
+// LicensesConfig controls the local detection of licenses, from license files
+// (LICENSE, COPYING, ...) and SPDX headers
+type LicensesConfig struct {
+	// Enabled detects the licenses of files and folders
+	Enabled bool `mapstructure:"enabled" json:"enabled"`
+	// Threshold is the share (0-1) of a bundled license text that a license file must contain to match it
+	Threshold float64 `mapstructure:"threshold" json:"threshold"`
+}
+
+// SamplingConfig controls how text files larger than MaxFileSize are read:
+// streamed and sampled within the file prompt budget instead of skipped
+type SamplingConfig struct {
+	// Enabled samples the large text files (logs, datasets, generated code, ...)
+	Enabled bool `mapstructure:"enabled" json:"enabled"`
+	// Windows is the number of evenly spaced windows taken between the head and the tail
+	Windows int `mapstructure:"windows" json:"windows"`
+}
+
+// BudgetConfig sizes the prompts sent to the models. All sizes are in
+// estimated tokens.
+type BudgetConfig struct {
+	// ContextWindows maps model names (case-insensitive) to their context window
+	ContextWindows map[string]int `mapstructure:"contextWindows" json:"contextWindows"`
+	// DefaultContextWindow applies to models missing from ContextWindows
+	DefaultContextWindow int `mapstructure:"defaultContextWindow" json:"defaultContextWindow"`
+	// ResponseTokens is kept free for the model's answer
+	ResponseTokens int `mapstructure:"responseTokens" json:"responseTokens"`
+	// MaxFileContentTokens caps the content of a single file sent for analysis (0 = whatever fits)
+	MaxFileContentTokens int `mapstructure:"maxFileContentTokens" json:"maxFileContentTokens"`
+	// MaxContextTokens caps the project and folder context added to each prompt (0 = no cap)
+	MaxContextTokens int `mapstructure:"maxContextTokens" json:"maxContextTokens"`
+}
+
+// TreeDiagramConfig controls the Mermaid and DOT renderings of the analyzed tree
+type TreeDiagramConfig struct {
+	// Style of the Mermaid diagram embedded in the markdown output: "flowchart", "mindmap" or "none"
+	Style string `mapstructure:"style" json:"style"`
+	// MaxDepth collapses folders deeper than this level (0 = unlimited)
+	MaxDepth int `mapstructure:"maxDepth" json:"maxDepth"`
+	// CollapseThreshold collapses folders with more direct entries than this (0 = never)
+	CollapseThreshold int `mapstructure:"collapseThreshold" json:"collapseThreshold"`
+}
+
 // ProviderModel represents an entry of the new array-based model selection API
 type ProviderModel struct {
 	Provider string `mapstructure:"provider" json:"provider"`
@@ -53,8 +224,24 @@ func GetDefaultConfig() *Config {
 		JSONOutputFile:            "output.json",
 		MarkdownOutputFile:        "output.md",
 		ReportOutputFile:          "report.md",
+		ReportJSONFile:            "report.json",
 		EstimationFile:            "estimation.md",
+		DiffOutputFile:            "diff.md",
+		DiffJSONFile:              "diff.json",
+		SecurityOutputFile:        "security.md",
+		LayoutOutputFile:          "layout.json",
+		DependencyMermaidFile:     "dependencies.mmd",
+		DependencyDotFile:         "dependencies.dot",
+		TreeDotFile:               "tree.dot",
+		HTMLOutputFile:            "output.html",
+		TreeDiagram:               TreeDiagramConfig{Style: "flowchart", MaxDepth: 3, CollapseThreshold: 25},
+		MarkdownStyle:             "tree",
+		ArchitectureDetail:        "standard",
+		OutputLanguage:            "en",
+		ContextFile:               ".archi/context.md",
+		FolderContextFile:         ".archi-context.md",
 		Mode:                      "full",
+		AnalysisProfile:           "description",
 		FileAnalysisModel:         "mistral-small-2501",
 		FolderAnalysisModel:       "mistral-small-2501",
 		ArchitectureAnalysisModel: "mistral-small-2501",
@@ -68,6 +255,22 @@ func GetDefaultConfig() *Config {
 		RequestDelay:              200 * time.Millisecond,
 		BatchSize:                 5,
 		Concurrency:               ConcurrencyConfig{ArchiAnalysis: 4, ReportChunking: 4},
+		Budget: BudgetConfig{
+			ContextWindows:       map[string]int{"mistral-small-2501": 32000, "magistral-small-2509": 40000},
+			DefaultContextWindow: 32000,
+			ResponseTokens:       2048,
+			MaxFileContentTokens: 4000,
+			MaxContextTokens:     1500,
+		},
+		Metadata:       MetadataConfig{Enabled: true, Git: true, GitMaxCommits: 10000},
+		History:        HistoryConfig{Enabled: true, MaxCommits: 1000, Hotspots: 10},
+		Duplicates:     DuplicatesConfig{Enabled: true, MinSize: 1},
+		NearDuplicates: NearDuplicatesConfig{Enabled: true, Threshold: 0.8, MinWords: 50, Clusters: 10},
+		Inventory:      InventoryConfig{Largest: 20},
+		Secrets:        SecretsConfig{Enabled: true, Entropy: 4.2},
+		PII:            PIIConfig{Enabled: true, Categories: PIICategories, Extensions: []string{".docx", ".xlsx", ".xls", ".pdf", ".eml"}},
+		Licenses:       LicensesConfig{Enabled: true, Threshold: 0.8},
+		Sampling:       SamplingConfig{Enabled: true, Windows: 3},
 	}
 }
 
@@ -81,8 +284,27 @@ func LoadConfig(configPath string) (*Config, error) {
 	v.SetDefault("jsonOutputFile", config.JSONOutputFile)
 	v.SetDefault("markdownOutputFile", config.MarkdownOutputFile)
 	v.SetDefault("reportOutputFile", config.ReportOutputFile)
+	v.SetDefault("reportJSONFile", config.ReportJSONFile)
 	v.SetDefault("estimationFile", config.EstimationFile)
+	v.SetDefault("diffOutputFile", config.DiffOutputFile)
+	v.SetDefault("diffJSONFile", config.DiffJSONFile)
+	v.SetDefault("securityOutputFile", config.SecurityOutputFile)
+	v.SetDefault("layoutOutputFile", config.LayoutOutputFile)
+	v.SetDefault("dependencyMermaidFile", config.DependencyMermaidFile)
+	v.SetDefault("dependencyDotFile", config.DependencyDotFile)
+	v.SetDefault("treeDotFile", config.TreeDotFile)
+	v.SetDefault("htmlOutputFile", config.HTMLOutputFile)
+	v.SetDefault("treeDiagram.style", config.TreeDiagram.Style)
+	v.SetDefault("treeDiagram.maxDepth", config.TreeDiagram.MaxDepth)
+	v.SetDefault("treeDiagram.collapseThreshold", config.TreeDiagram.CollapseThreshold)
+	v.SetDefault("markdownStyle", config.MarkdownStyle)
+	v.SetDefault("architectureDetail", config.ArchitectureDetail)
+	v.SetDefault("outputLanguage", config.OutputLanguage)
+	v.SetDefault("context", config.Context)
+	v.SetDefault("contextFile", config.ContextFile)
+	v.SetDefault("folderContextFile", config.FolderContextFile)
 	v.SetDefault("mode", config.Mode)
+	v.SetDefault("analysisProfile", config.AnalysisProfile)
 	v.SetDefault("fileAnalysisModel", config.FileAnalysisModel)
 	v.SetDefault("folderAnalysisModel", config.FolderAnalysisModel)
 	v.SetDefault("architectureAnalysisModel", config.ArchitectureAnalysisModel)
@@ -97,6 +319,34 @@ func LoadConfig(configPath string) (*Config, error) {
 	v.SetDefault("batchSize", config.BatchSize)
 	v.SetDefault("concurrency.archiAnalysis", config.Concurrency.ArchiAnalysis)
 	v.SetDefault("concurrency.reportChunking", config.Concurrency.ReportChunking)
+	v.SetDefault("budget.contextWindows", config.Budget.ContextWindows)
+	v.SetDefault("budget.defaultContextWindow", config.Budget.DefaultContextWindow)
+	v.SetDefault("budget.responseTokens", config.Budget.ResponseTokens)
+	v.SetDefault("budget.maxFileContentTokens", config.Budget.MaxFileContentTokens)
+	v.SetDefault("budget.maxContextTokens", config.Budget.MaxContextTokens)
+	v.SetDefault("promptDir", config.PromptDir)
+	v.SetDefault("metadata.enabled", config.Metadata.Enabled)
+	v.SetDefault("metadata.git", config.Metadata.Git)
+	v.SetDefault("metadata.gitMaxCommits", config.Metadata.GitMaxCommits)
+	v.SetDefault("history.enabled", config.History.Enabled)
+	v.SetDefault("history.maxCommits", config.History.MaxCommits)
+	v.SetDefault("history.hotspots", config.History.Hotspots)
+	v.SetDefault("duplicates.enabled", config.Duplicates.Enabled)
+	v.SetDefault("duplicates.minSize", config.Duplicates.MinSize)
+	v.SetDefault("nearDuplicates.enabled", config.NearDuplicates.Enabled)
+	v.SetDefault("nearDuplicates.threshold", config.NearDuplicates.Threshold)
+	v.SetDefault("nearDuplicates.minWords", config.NearDuplicates.MinWords)
+	v.SetDefault("nearDuplicates.clusters", config.NearDuplicates.Clusters)
+	v.SetDefault("inventory.largest", config.Inventory.Largest)
+	v.SetDefault("secrets.enabled", config.Secrets.Enabled)
+	v.SetDefault("secrets.entropy", config.Secrets.Entropy)
+	v.SetDefault("pii.enabled", config.PII.Enabled)
+	v.SetDefault("licenses.enabled", config.Licenses.Enabled)
+	v.SetDefault("licenses.threshold", config.Licenses.Threshold)
+	v.SetDefault("sampling.enabled", config.Sampling.Enabled)
+	v.SetDefault("sampling.windows", config.Sampling.Windows)
+	v.SetDefault("pii.categories", config.PII.Categories)
+	v.SetDefault("pii.extensions", config.PII.Extensions)
 
 	if configPath != "" {
 		v.SetConfigFile(configPath)
@@ -204,13 +454,106 @@ func validateConfig(config *Config) error {
 	if config.Concurrency.ReportChunking <= 0 {
 		return fmt.Errorf("concurrency.reportChunking must be >= 1")
 	}
+	if config.Metadata.GitMaxCommits < 0 {
+		return fmt.Errorf("metadata.gitMaxCommits cannot be negative")
+	}
+	if config.History.MaxCommits < 0 || config.History.Hotspots < 0 {
+		return fmt.Errorf("history.maxCommits and history.hotspots cannot be negative")
+	}
+	if config.Duplicates.MinSize < 0 {
+		return fmt.Errorf("duplicates.minSize cannot be negative")
+	}
+	if config.NearDuplicates.Threshold <= 0 || config.NearDuplicates.Threshold > 1 {
+		return fmt.Errorf("nearDuplicates.threshold must be between 0 and 1")
+	}
+	if config.NearDuplicates.MinWords < 0 || config.NearDuplicates.Clusters < 0 {
+		return fmt.Errorf("nearDuplicates.minWords and nearDuplicates.clusters cannot be negative")
+	}
+	if config.Inventory.Largest < 0 {
+		return fmt.Errorf("inventory.largest cannot be negative")
+	}
+	if config.Secrets.Entropy < 0 {
+		return fmt.Errorf("secrets.entropy cannot be negative")
+	}
+	for _, rule := range config.Secrets.Rules {
+		if strings.TrimSpace(rule.Name) == "" {
+			return fmt.Errorf("secrets.rules: every rule needs a name")
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("secrets.rules[%s]: invalid pattern: %v", rule.Name, err)
+		}
+	}
+	if config.Licenses.Threshold <= 0 || config.Licenses.Threshold > 1 {
+		return fmt.Errorf("licenses.threshold must be between 0 (excluded) and 1")
+	}
+	if config.Sampling.Windows < 0 {
+		return fmt.Errorf("sampling.windows cannot be negative")
+	}
+	for _, category := range config.PII.Categories {
+		if !slices.Contains(PIICategories, category) {
+			return fmt.Errorf("pii.categories: unknown category %q (valid: %s)", category, strings.Join(PIICategories, ", "))
+		}
+	}
+	if config.Budget.DefaultContextWindow <= 0 {
+		return fmt.Errorf("budget.defaultContextWindow must be >= 1")
+	}
+	if config.Budget.ResponseTokens < 0 || config.Budget.MaxFileContentTokens < 0 || config.Budget.MaxContextTokens < 0 {
+		return fmt.Errorf("budget.responseTokens, budget.maxFileContentTokens and budget.maxContextTokens cannot be negative")
+	}
+	if config.Budget.ResponseTokens >= config.Budget.DefaultContextWindow {
+		return fmt.Errorf("budget.responseTokens must be smaller than budget.defaultContextWindow")
+	}
+	for model, window := range config.Budget.ContextWindows {
+		if window <= 0 {
+			return fmt.Errorf("budget.contextWindows[%s] must be >= 1", model)
+		}
+	}
 	switch strings.ToLower(strings.TrimSpace(config.Mode)) {
-	case "", "full", "description-only", "folder-only":
+	case "", "full", "description-only", "folder-only", "flat":
 		if strings.TrimSpace(config.Mode) == "" {
 			config.Mode = "full"
 		}
 	default:
-		return fmt.Errorf("mode must be one of: full, description-only, folder-only")
+		return fmt.Errorf("mode must be one of: full, description-only, folder-only, flat")
+	}
+	switch strings.ToLower(strings.TrimSpace(config.AnalysisProfile)) {
+	case "":
+		config.AnalysisProfile = "description"
+	case "description", "security":
+		config.AnalysisProfile = strings.ToLower(strings.TrimSpace(config.AnalysisProfile))
+	default:
+		return fmt.Errorf("analysisProfile must be one of: description, security")
+	}
+	switch strings.ToLower(strings.TrimSpace(config.MarkdownStyle)) {
+	case "":
+		config.MarkdownStyle = "tree"
+	case "tree", "documentation":
+		config.MarkdownStyle = strings.ToLower(strings.TrimSpace(config.MarkdownStyle))
+	default:
+		return fmt.Errorf("markdownStyle must be one of: tree, documentation")
+	}
+	config.OutputLanguage = strings.TrimSpace(config.OutputLanguage)
+	if config.OutputLanguage == "" {
+		config.OutputLanguage = "en"
+	}
+	switch strings.ToLower(strings.TrimSpace(config.ArchitectureDetail)) {
+	case "":
+		config.ArchitectureDetail = "standard"
+	case "minimal", "standard", "full":
+		config.ArchitectureDetail = strings.ToLower(strings.TrimSpace(config.ArchitectureDetail))
+	default:
+		return fmt.Errorf("architectureDetail must be one of: minimal, standard, full")
+	}
+	switch strings.ToLower(strings.TrimSpace(config.TreeDiagram.Style)) {
+	case "":
+		config.TreeDiagram.Style = "flowchart"
+	case "flowchart", "mindmap", "none":
+		config.TreeDiagram.Style = strings.ToLower(strings.TrimSpace(config.TreeDiagram.Style))
+	default:
+		return fmt.Errorf("treeDiagram.style must be one of: flowchart, mindmap, none")
+	}
+	if config.TreeDiagram.MaxDepth < 0 || config.TreeDiagram.CollapseThreshold < 0 {
+		return fmt.Errorf("treeDiagram.maxDepth and treeDiagram.collapseThreshold cannot be negative")
 	}
 	// Validate model configuration: each operation must have either a single string (Mistral-only) or a non-empty array of provider/models
 	type pair struct {
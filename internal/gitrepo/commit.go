@@ -0,0 +1,435 @@
+package gitrepo
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signature is the author or committer of a commit.
+type Signature struct {
+	Name  string
+	Email string
+	When  time.Time
+}
+
+// Commit is a parsed commit object.
+type Commit struct {
+	Hash      Hash
+	Tree      Hash
+	Parents   []Hash
+	Author    Signature
+	Committer Signature
+	Message   string
+}
+
+// Commit reads and parses a commit. The commits at the boundary of a shallow
+// clone have no parents, as in git: their parents are not in the repository.
+func (r *Repo) Commit(h Hash) (*Commit, error) {
+	obj, err := r.object(h)
+	if err != nil {
+		return nil, err
+	}
+	if obj.kind != kindCommit {
+		return nil, fmt.Errorf("%s is a %s, not a commit", h, obj.kind)
+	}
+
+	c := &Commit{Hash: h}
+	data := obj.data
+	for len(data) > 0 {
+		nl := bytes.IndexByte(data, '\n')
+		if nl < 0 {
+			nl = len(data)
+		}
+		line := string(data[:nl])
+		data = data[min(nl+1, len(data)):]
+		if line == "" {
+			c.Message = string(data)
+			break
+		}
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "tree":
+			c.Tree, err = ParseHash(value)
+		case "parent":
+			var p Hash
+			if p, err = ParseHash(value); err == nil {
+				c.Parents = append(c.Parents, p)
+			}
+		case "author":
+			c.Author = parseSignature(value)
+		case "committer":
+			c.Committer = parseSignature(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("commit %s: %w", h, err)
+		}
+	}
+	if r.isShallow(h) {
+		c.Parents = nil
+	}
+	return c, nil
+}
+
+// isShallow reports whether h is listed in the shallow file, which records
+// the boundary commits of a shallow clone.
+func (r *Repo) isShallow(h Hash) bool {
+	r.shallowOnce.Do(func() {
+		data, err := os.ReadFile(filepath.Join(r.commonDir, "shallow"))
+		if err != nil {
+			return
+		}
+		r.shallow = make(map[Hash]bool)
+		for _, line := range strings.Fields(string(data)) {
+			if b, err := ParseHash(line); err == nil {
+				r.shallow[b] = true
+			}
+		}
+	})
+	return r.shallow[h]
+}
+
+// parseSignature parses "Name <email> 1700000000 +0100".
+func parseSignature(s string) Signature {
+	var sig Signature
+	lt, gt := strings.IndexByte(s, '<'), strings.LastIndexByte(s, '>')
+	if lt < 0 || gt < lt {
+		sig.Name = strings.TrimSpace(s)
+		return sig
+	}
+	sig.Name = strings.TrimSpace(s[:lt])
+	sig.Email = s[lt+1 : gt]
+	fields := strings.Fields(s[gt+1:])
+	if len(fields) > 0 {
+		if secs, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+			loc := time.UTC
+			if len(fields) > 1 && len(fields[1]) == 5 {
+				hours, _ := strconv.Atoi(fields[1][1:3])
+				minutes, _ := strconv.Atoi(fields[1][3:5])
+				offset := hours*3600 + minutes*60
+				if fields[1][0] == '-' {
+					offset = -offset
+				}
+				loc = time.FixedZone(fields[1], offset)
+			}
+			sig.When = time.Unix(secs, 0).In(loc)
+		}
+	}
+	return sig
+}
+
+// TreeEntry is an entry of a tree object.
+type TreeEntry struct {
+	Name string
+	Mode uint32
+	Hash Hash
+}
+
+// IsDir reports whether the entry is a subtree.
+func (e TreeEntry) IsDir() bool {
+	return e.Mode == 0o40000
+}
+
+// isSubmodule reports whether the entry is a gitlink, whose object lives in
+// another repository.
+func (e TreeEntry) isSubmodule() bool {
+	return e.Mode == 0o160000
+}
+
+// Tree reads the entries of a tree object.
+func (r *Repo) Tree(h Hash) ([]TreeEntry, error) {
+	obj, err := r.object(h)
+	if err != nil {
+		return nil, err
+	}
+	if obj.kind != kindTree {
+		return nil, fmt.Errorf("%s is a %s, not a tree", h, obj.kind)
+	}
+
+	var entries []TreeEntry
+	data := obj.data
+	for len(data) > 0 {
+		sp := bytes.IndexByte(data, ' ')
+		nul := bytes.IndexByte(data, 0)
+		if sp < 0 || nul < sp || nul+21 > len(data) {
+			return nil, fmt.Errorf("tree %s: malformed entry", h)
+		}
+		mode, err := strconv.ParseUint(string(data[:sp]), 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("tree %s: malformed mode", h)
+		}
+		e := TreeEntry{Name: string(data[sp+1 : nul]), Mode: uint32(mode)}
+		copy(e.Hash[:], data[nul+1:nul+21])
+		entries = append(entries, e)
+		data = data[nul+21:]
+	}
+	return entries, nil
+}
+
+// Files lists the files of a tree recursively, by slash-separated path.
+// Submodules are skipped.
+func (r *Repo) Files(tree Hash) (map[string]Hash, error) {
+	files := make(map[string]Hash)
+	var walk func(h Hash, prefix string) error
+	walk = func(h Hash, prefix string) error {
+		entries, err := r.Tree(h)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			switch {
+			case e.IsDir():
+				if err := walk(e.Hash, prefix+e.Name+"/"); err != nil {
+					return err
+				}
+			case !e.isSubmodule():
+				files[prefix+e.Name] = e.Hash
+			}
+		}
+		return nil
+	}
+	return files, walk(tree, "")
+}
+
+// Action is the kind of a change between two trees.
+type Action string
+
+const (
+	Added    Action = "added"
+	Deleted  Action = "deleted"
+	Modified Action = "modified"
+	Renamed  Action = "renamed"
+)
+
+// Change is a file that differs between two trees. From is the blob before
+// the change and To the blob after; one of them is zero for additions and
+// deletions. OldPath is set for renames.
+type Change struct {
+	Path    string
+	OldPath string
+	Action  Action
+	From    Hash
+	To      Hash
+}
+
+// DiffTrees lists the files that differ between two trees, sorted by path.
+// A zero hash stands for the empty tree. Subtrees with the same hash are not
+// descended into. Deleted and added files with the same content are reported
+// as renames.
+func (r *Repo) DiffTrees(from, to Hash) ([]Change, error) {
+	var changes []Change
+	if err := r.diffTrees(from, to, "", &changes); err != nil {
+		return nil, err
+	}
+	changes = detectRenames(changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+func (r *Repo) diffTrees(from, to Hash, prefix string, changes *[]Change) error {
+	if from == to {
+		return nil
+	}
+	entries := func(h Hash) (map[string]TreeEntry, error) {
+		m := make(map[string]TreeEntry)
+		if h.IsZero() {
+			return m, nil
+		}
+		list, err := r.Tree(h)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range list {
+			if !e.isSubmodule() {
+				m[e.Name] = e
+			}
+		}
+		return m, nil
+	}
+	old, err := entries(from)
+	if err != nil {
+		return err
+	}
+	cur, err := entries(to)
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]bool)
+	for name := range old {
+		names[name] = true
+	}
+	for name := range cur {
+		names[name] = true
+	}
+	for name := range names {
+		o, inOld := old[name]
+		c, inCur := cur[name]
+		path := prefix + name
+		if inOld && inCur && o.Hash == c.Hash && o.IsDir() == c.IsDir() {
+			continue
+		}
+
+		var oldTree, curTree Hash
+		var oldBlob, curBlob Hash
+		if inOld {
+			if o.IsDir() {
+				oldTree = o.Hash
+			} else {
+				oldBlob = o.Hash
+			}
+		}
+		if inCur {
+			if c.IsDir() {
+				curTree = c.Hash
+			} else {
+				curBlob = c.Hash
+			}
+		}
+		if !oldTree.IsZero() || !curTree.IsZero() {
+			if err := r.diffTrees(oldTree, curTree, path+"/", changes); err != nil {
+				return err
+			}
+		}
+		switch {
+		case !oldBlob.IsZero() && !curBlob.IsZero():
+			*changes = append(*changes, Change{Path: path, Action: Modified, From: oldBlob, To: curBlob})
+		case !oldBlob.IsZero():
+			*changes = append(*changes, Change{Path: path, Action: Deleted, From: oldBlob})
+		case !curBlob.IsZero():
+			*changes = append(*changes, Change{Path: path, Action: Added, To: curBlob})
+		}
+	}
+	return nil
+}
+
+// detectRenames pairs deletions and additions of identical content.
+func detectRenames(changes []Change) []Change {
+	deleted := make(map[Hash][]int)
+	for i, c := range changes {
+		if c.Action == Deleted {
+			deleted[c.From] = append(deleted[c.From], i)
+		}
+	}
+	if len(deleted) == 0 {
+		return changes
+	}
+	drop := make(map[int]bool)
+	for i, c := range changes {
+		if c.Action != Added {
+			continue
+		}
+		candidates := deleted[c.To]
+		if len(candidates) == 0 {
+			continue
+		}
+		j := candidates[0]
+		deleted[c.To] = candidates[1:]
+		changes[i] = Change{Path: c.Path, OldPath: changes[j].Path, Action: Renamed, From: c.To, To: c.To}
+		drop[j] = true
+	}
+	kept := changes[:0]
+	for i, c := range changes {
+		if !drop[i] {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// CommitChanges lists the files changed by a commit. The changes of a root
+// commit are all additions. For a merge, only the files that differ from
+// every parent are reported, since the others come unchanged from one side.
+func (r *Repo) CommitChanges(c *Commit) ([]Change, error) {
+	if len(c.Parents) == 0 {
+		return r.DiffTrees(Hash{}, c.Tree)
+	}
+	first, err := r.Commit(c.Parents[0])
+	if err != nil {
+		return nil, err
+	}
+	changes, err := r.DiffTrees(first.Tree, c.Tree)
+	if err != nil || len(c.Parents) == 1 {
+		return changes, err
+	}
+
+	for _, p := range c.Parents[1:] {
+		parent, err := r.Commit(p)
+		if err != nil {
+			return nil, err
+		}
+		other, err := r.DiffTrees(parent.Tree, c.Tree)
+		if err != nil {
+			return nil, err
+		}
+		differs := make(map[string]bool, len(other))
+		for _, ch := range other {
+			differs[ch.Path] = true
+		}
+		kept := changes[:0]
+		for _, ch := range changes {
+			if differs[ch.Path] {
+				kept = append(kept, ch)
+			}
+		}
+		changes = kept
+	}
+	return changes, nil
+}
+
+// Walk visits the commits reachable from start, newest first by committer
+// date, with the files each one changed. Returning false from fn stops the
+// walk.
+func (r *Repo) Walk(start Hash, fn func(c *Commit, changes []Change) bool) error {
+	first, err := r.Commit(start)
+	if err != nil {
+		return err
+	}
+	queue := &commitQueue{first}
+	seen := map[Hash]bool{start: true}
+	for queue.Len() > 0 {
+		c := heap.Pop(queue).(*Commit)
+		changes, err := r.CommitChanges(c)
+		if err != nil {
+			return err
+		}
+		if !fn(c, changes) {
+			return nil
+		}
+		for _, p := range c.Parents {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			parent, err := r.Commit(p)
+			if err != nil {
+				// A repository missing objects ends the walk on that side
+				continue
+			}
+			heap.Push(queue, parent)
+		}
+	}
+	return nil
+}
+
+// commitQueue orders commits newest first.
+type commitQueue []*Commit
+
+func (q commitQueue) Len() int { return len(q) }
+func (q commitQueue) Less(i, j int) bool {
+	return q[i].Committer.When.After(q[j].Committer.When)
+}
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *commitQueue) Push(x any)   { *q = append(*q, x.(*Commit)) }
+func (q *commitQueue) Pop() any {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
@@ -0,0 +1,178 @@
+package gitrepo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffTrees(t *testing.T) {
+	const (
+		hello      = "ce013625030ba8dba906f756967f9e9ca394464a"
+		helloWorld = "3b18e512dba79e4c8300dd08aeb37f8e728b8dad"
+		bee        = "af9c6fd168ea28cf99aa2c2dd9057a8b720e2262"
+		feature    = "a7453f07505c42ea8d6fdda75fa91710c81c53d6"
+		bigV1      = "7972c09aa90a9b3d8519064681f2cca009f8777c"
+		bigV2      = "dbd76b1c15c7f2996384aad22a122e845315cbd9"
+	)
+	type change struct {
+		Path, OldPath string
+		Action        Action
+		From, To      string
+	}
+	tests := []struct {
+		name     string
+		from, to string // commits; "" for the empty tree
+		want     []change
+	}{
+		{
+			name: "root commit",
+			to:   initialCommit,
+			want: []change{
+				{Path: "a.txt", Action: Added, To: hello},
+				{Path: "big.txt", Action: Added, To: bigV1},
+				{Path: "dir/b.txt", Action: Added, To: bee},
+			},
+		},
+		{
+			name: "modification",
+			from: initialCommit,
+			to:   editCommit,
+			want: []change{
+				{Path: "big.txt", Action: Modified, From: bigV1, To: bigV2},
+			},
+		},
+		{
+			name: "rename into a new folder",
+			from: editCommit,
+			to:   renameCommit,
+			want: []change{
+				{Path: "a.txt", Action: Modified, From: hello, To: helloWorld},
+				{Path: "lib/b.txt", OldPath: "dir/b.txt", Action: Renamed, From: bee, To: bee},
+			},
+		},
+		{
+			name: "rename backwards",
+			from: renameCommit,
+			to:   editCommit,
+			want: []change{
+				{Path: "a.txt", Action: Modified, From: helloWorld, To: hello},
+				{Path: "dir/b.txt", OldPath: "lib/b.txt", Action: Renamed, From: bee, To: bee},
+			},
+		},
+		{
+			name: "deletion",
+			from: mergeCommit,
+			to:   renameCommit,
+			want: []change{
+				{Path: "feature.txt", Action: Deleted, From: feature},
+			},
+		},
+		{
+			name: "same tree",
+			from: mergeCommit,
+			to:   mergeCommit,
+		},
+	}
+	for _, fixture := range fixtures {
+		r := openFixture(t, fixture)
+		tree := func(commit string) Hash {
+			if commit == "" {
+				return Hash{}
+			}
+			c, err := r.Commit(mustHash(t, commit))
+			if err != nil {
+				t.Fatalf("Commit(%s): %v", commit, err)
+			}
+			return c.Tree
+		}
+		hash := func(s string) string {
+			if s == "" {
+				return Hash{}.String()
+			}
+			return s
+		}
+		for _, tt := range tests {
+			t.Run(fixture+"/"+tt.name, func(t *testing.T) {
+				changes, err := r.DiffTrees(tree(tt.from), tree(tt.to))
+				if err != nil {
+					t.Fatalf("DiffTrees: %v", err)
+				}
+				var got []change
+				for _, ch := range changes {
+					got = append(got, change{ch.Path, ch.OldPath, ch.Action, ch.From.String(), ch.To.String()})
+				}
+				var want []change
+				for _, ch := range tt.want {
+					ch.From, ch.To = hash(ch.From), hash(ch.To)
+					want = append(want, ch)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("DiffTrees:\n got %+v\nwant %+v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestCommitChangesOfMerge(t *testing.T) {
+	for _, fixture := range fixtures {
+		t.Run(fixture, func(t *testing.T) {
+			r := openFixture(t, fixture)
+			c, err := r.Commit(mustHash(t, mergeCommit))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Every file of the merge comes unchanged from one of its parents
+			changes, err := r.CommitChanges(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(changes) != 0 {
+				t.Errorf("CommitChanges(merge) = %+v, want no change", changes)
+			}
+		})
+	}
+}
+
+func TestWalkShallow(t *testing.T) {
+	// The clone of depth 2 holds the merge and its two parents, whose own
+	// parent is missing
+	r := openFixture(t, "shallow.git")
+	head, err := r.Resolve("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var visited []string
+	added := make(map[string][]string)
+	err = r.Walk(head, func(c *Commit, changes []Change) bool {
+		visited = append(visited, c.Hash.String())
+		for _, ch := range changes {
+			if ch.Action != Added {
+				t.Errorf("commit %s: %s is %s, want added", c.Hash, ch.Path, ch.Action)
+			}
+			added[c.Hash.String()] = append(added[c.Hash.String()], ch.Path)
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if want := []string{mergeCommit, renameCommit, featureCommit}; !reflect.DeepEqual(visited, want) {
+		t.Errorf("Walk visited %v, want %v", visited, want)
+	}
+	// The boundary commits are diffed against the empty tree, like root commits
+	if want := []string{"a.txt", "big.txt", "lib/b.txt"}; !reflect.DeepEqual(added[renameCommit], want) {
+		t.Errorf("changes of the boundary commit %s = %v, want %v", renameCommit, added[renameCommit], want)
+	}
+	if want := []string{"a.txt", "big.txt", "dir/b.txt", "feature.txt"}; !reflect.DeepEqual(added[featureCommit], want) {
+		t.Errorf("changes of the boundary commit %s = %v, want %v", featureCommit, added[featureCommit], want)
+	}
+	if len(added[mergeCommit]) != 0 {
+		t.Errorf("changes of the merge = %v, want none", added[mergeCommit])
+	}
+
+	if _, err := r.Resolve("HEAD~2"); err == nil || !strings.Contains(err.Error(), "beyond the root commit") {
+		t.Errorf("Resolve(HEAD~2) = %v, want an error beyond the root commit", err)
+	}
+}
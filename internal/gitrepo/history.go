@@ -0,0 +1,50 @@
+package gitrepo
+
+import "bytes"
+
+// LineStats counts the lines added and deleted by a change. Lines are
+// compared as multisets, which matches a line diff except for moved lines.
+// Binary blobs count no lines.
+func (r *Repo) LineStats(ch Change) (added, deleted int, err error) {
+	lines := func(h Hash) (map[string]int, error) {
+		counts := make(map[string]int)
+		if h.IsZero() {
+			return counts, nil
+		}
+		data, err := r.Blob(h)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+			return counts, nil
+		}
+		for _, line := range bytes.SplitAfter(data, []byte{'\n'}) {
+			if len(line) > 0 {
+				counts[string(bytes.TrimSuffix(line, []byte{'\n'}))]++
+			}
+		}
+		return counts, nil
+	}
+	if ch.From == ch.To {
+		return 0, 0, nil
+	}
+	old, err := lines(ch.From)
+	if err != nil {
+		return 0, 0, err
+	}
+	cur, err := lines(ch.To)
+	if err != nil {
+		return 0, 0, err
+	}
+	for line, n := range cur {
+		if n > old[line] {
+			added += n - old[line]
+		}
+	}
+	for line, n := range old {
+		if n > cur[line] {
+			deleted += n - cur[line]
+		}
+	}
+	return added, deleted, nil
+}
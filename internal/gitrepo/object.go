@@ -0,0 +1,148 @@
+package gitrepo
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// maxCachedObjects bounds the decoded object cache; trees and commits are
+// read repeatedly while walking history.
+const maxCachedObjects = 4096
+
+type objectKind int
+
+const (
+	kindCommit objectKind = 1
+	kindTree   objectKind = 2
+	kindBlob   objectKind = 3
+	kindTag    objectKind = 4
+)
+
+func (k objectKind) String() string {
+	switch k {
+	case kindCommit:
+		return "commit"
+	case kindTree:
+		return "tree"
+	case kindBlob:
+		return "blob"
+	case kindTag:
+		return "tag"
+	}
+	return "object"
+}
+
+func parseKind(s string) (objectKind, error) {
+	switch s {
+	case "commit":
+		return kindCommit, nil
+	case "tree":
+		return kindTree, nil
+	case "blob":
+		return kindBlob, nil
+	case "tag":
+		return kindTag, nil
+	}
+	return 0, fmt.Errorf("unknown object type %q", s)
+}
+
+type object struct {
+	kind objectKind
+	data []byte
+}
+
+// object reads an object from the loose store or the packfiles.
+func (r *Repo) object(h Hash) (*object, error) {
+	r.mu.Lock()
+	obj, ok := r.cache[h]
+	r.mu.Unlock()
+	if ok {
+		return obj, nil
+	}
+
+	obj, err := r.readLoose(h)
+	if os.IsNotExist(err) {
+		obj, err = r.readPacked(h)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Blobs are read once and can be large, so only metadata objects are kept
+	if obj.kind != kindBlob {
+		r.mu.Lock()
+		if len(r.cache) >= maxCachedObjects {
+			r.cache = make(map[Hash]*object)
+		}
+		r.cache[h] = obj
+		r.mu.Unlock()
+	}
+	return obj, nil
+}
+
+func (r *Repo) readLoose(h Hash) (*object, error) {
+	name := h.String()
+	f, err := os.Open(filepath.Join(r.commonDir, "objects", name[:2], name[2:]))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("object %s: %w", name, err)
+	}
+	defer zr.Close()
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("object %s: %w", name, err)
+	}
+
+	nul := bytes.IndexByte(raw, 0)
+	if nul < 0 {
+		return nil, fmt.Errorf("object %s: malformed header", name)
+	}
+	header := bytes.SplitN(raw[:nul], []byte(" "), 2)
+	if len(header) != 2 {
+		return nil, fmt.Errorf("object %s: malformed header", name)
+	}
+	kind, err := parseKind(string(header[0]))
+	if err != nil {
+		return nil, fmt.Errorf("object %s: %w", name, err)
+	}
+	size, err := strconv.Atoi(string(header[1]))
+	if err != nil || size != len(raw)-nul-1 {
+		return nil, fmt.Errorf("object %s: size mismatch", name)
+	}
+	return &object{kind: kind, data: raw[nul+1:]}, nil
+}
+
+func (r *Repo) readPacked(h Hash) (*object, error) {
+	packs, err := r.loadPacks()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range packs {
+		if offset, ok := p.idx.find(h); ok {
+			return p.readAt(r, offset)
+		}
+	}
+	return nil, fmt.Errorf("object %s not found", h)
+}
+
+// Blob returns the content of a blob object.
+func (r *Repo) Blob(h Hash) ([]byte, error) {
+	obj, err := r.object(h)
+	if err != nil {
+		return nil, err
+	}
+	if obj.kind != kindBlob {
+		return nil, fmt.Errorf("%s is a %s, not a blob", h, obj.kind)
+	}
+	return obj.data, nil
+}
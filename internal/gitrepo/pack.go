@@ -0,0 +1,329 @@
+package gitrepo
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	packOfsDelta = 6
+	packRefDelta = 7
+)
+
+// pack is a packfile with its version 2 index.
+type pack struct {
+	path string
+	idx  *packIndex
+
+	mu    sync.Mutex
+	f     *os.File
+	bases map[uint64]*object // recently decoded delta bases by offset
+}
+
+// maxCachedBases bounds the delta base cache of each pack.
+const maxCachedBases = 256
+
+type packIndex struct {
+	fanout  [256]uint32
+	hashes  []Hash
+	offsets []uint64
+}
+
+func (r *Repo) loadPacks() ([]*pack, error) {
+	r.packsOnce.Do(func() {
+		dir := filepath.Join(r.commonDir, "objects", "pack")
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			return
+		}
+		if err != nil {
+			r.packsErr = err
+			return
+		}
+		for _, e := range entries {
+			if !strings.HasSuffix(e.Name(), ".idx") {
+				continue
+			}
+			idx, err := readPackIndex(filepath.Join(dir, e.Name()))
+			if err != nil {
+				r.packsErr = err
+				return
+			}
+			r.packs = append(r.packs, &pack{path: filepath.Join(dir, strings.TrimSuffix(e.Name(), ".idx")+".pack"), idx: idx})
+		}
+	})
+	return r.packs, r.packsErr
+}
+
+func readPackIndex(path string) (*packIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 8+256*4 || !bytes.Equal(data[:4], []byte{0xff, 't', 'O', 'c'}) || binary.BigEndian.Uint32(data[4:8]) != 2 {
+		return nil, fmt.Errorf("%s: unsupported pack index version", path)
+	}
+
+	idx := &packIndex{}
+	pos := 8
+	for i := range idx.fanout {
+		idx.fanout[i] = binary.BigEndian.Uint32(data[pos:])
+		pos += 4
+	}
+	n := int(idx.fanout[255])
+	// names, CRC32s, 4-byte offsets, then 8-byte offsets for large packs
+	if len(data) < pos+n*(20+4+4) {
+		return nil, fmt.Errorf("%s: truncated pack index", path)
+	}
+	idx.hashes = make([]Hash, n)
+	for i := 0; i < n; i++ {
+		copy(idx.hashes[i][:], data[pos:pos+20])
+		pos += 20
+	}
+	pos += n * 4
+	small := pos
+	large := small + n*4
+	idx.offsets = make([]uint64, n)
+	for i := 0; i < n; i++ {
+		off := binary.BigEndian.Uint32(data[small+i*4:])
+		if off&0x80000000 == 0 {
+			idx.offsets[i] = uint64(off)
+			continue
+		}
+		at := large + int(off&0x7fffffff)*8
+		if at+8 > len(data) {
+			return nil, fmt.Errorf("%s: truncated pack index", path)
+		}
+		idx.offsets[i] = binary.BigEndian.Uint64(data[at:])
+	}
+	return idx, nil
+}
+
+func (idx *packIndex) find(h Hash) (uint64, bool) {
+	lo := 0
+	if h[0] > 0 {
+		lo = int(idx.fanout[h[0]-1])
+	}
+	hi := int(idx.fanout[h[0]])
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(idx.hashes[lo+i][:], h[:]) >= 0
+	})
+	if i < hi && idx.hashes[i] == h {
+		return idx.offsets[i], true
+	}
+	return 0, false
+}
+
+func (idx *packIndex) withPrefix(prefix string) []Hash {
+	var found []Hash
+	for _, h := range idx.hashes {
+		if strings.HasPrefix(hex.EncodeToString(h[:]), prefix) {
+			found = append(found, h)
+		}
+	}
+	return found
+}
+
+// readAt decodes the object at offset, applying deltas.
+func (p *pack) readAt(r *Repo, offset uint64) (*object, error) {
+	kind, data, base, baseOffset, err := p.readEntry(offset)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case packOfsDelta:
+		baseObj, err := p.base(r, baseOffset)
+		if err != nil {
+			return nil, err
+		}
+		return applyDeltaObject(baseObj, data)
+	case packRefDelta:
+		baseObj, err := r.object(base)
+		if err != nil {
+			return nil, err
+		}
+		return applyDeltaObject(baseObj, data)
+	}
+	return &object{kind: objectKind(kind), data: data}, nil
+}
+
+// base decodes a delta base, keeping it since chains share their bases.
+func (p *pack) base(r *Repo, offset uint64) (*object, error) {
+	p.mu.Lock()
+	obj, ok := p.bases[offset]
+	p.mu.Unlock()
+	if ok {
+		return obj, nil
+	}
+	obj, err := p.readAt(r, offset)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	if p.bases == nil || len(p.bases) >= maxCachedBases {
+		p.bases = make(map[uint64]*object)
+	}
+	p.bases[offset] = obj
+	p.mu.Unlock()
+	return obj, nil
+}
+
+func applyDeltaObject(base *object, delta []byte) (*object, error) {
+	data, err := applyDelta(base.data, delta)
+	if err != nil {
+		return nil, err
+	}
+	return &object{kind: base.kind, data: data}, nil
+}
+
+// readEntry reads the header and the inflated payload of a pack entry.
+func (p *pack) readEntry(offset uint64) (kind int, data []byte, base Hash, baseOffset uint64, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.f == nil {
+		if p.f, err = os.Open(p.path); err != nil {
+			return
+		}
+	}
+
+	sr := io.NewSectionReader(p.f, int64(offset), 1<<62)
+	br := &byteReader{r: sr}
+	c := br.readByte()
+	kind = int(c>>4) & 7
+	size := uint64(c & 0x0f)
+	for shift := uint(4); c&0x80 != 0; shift += 7 {
+		c = br.readByte()
+		size |= uint64(c&0x7f) << shift
+	}
+
+	switch kind {
+	case packOfsDelta:
+		c = br.readByte()
+		rel := uint64(c & 0x7f)
+		for c&0x80 != 0 {
+			c = br.readByte()
+			rel = ((rel + 1) << 7) | uint64(c&0x7f)
+		}
+		if rel > offset {
+			err = fmt.Errorf("%s: bad delta offset at %d", p.path, offset)
+			return
+		}
+		baseOffset = offset - rel
+	case packRefDelta:
+		for i := range base {
+			base[i] = br.readByte()
+		}
+	}
+	if br.err != nil {
+		err = fmt.Errorf("%s: %w", p.path, br.err)
+		return
+	}
+
+	zr, err := zlib.NewReader(io.NewSectionReader(p.f, int64(offset)+br.n, 1<<62))
+	if err != nil {
+		err = fmt.Errorf("%s: %w", p.path, err)
+		return
+	}
+	defer zr.Close()
+	data = make([]byte, size)
+	if _, err = io.ReadFull(zr, data); err != nil {
+		err = fmt.Errorf("%s: entry at %d: %w", p.path, offset, err)
+	}
+	return
+}
+
+type byteReader struct {
+	r   io.Reader
+	n   int64
+	err error
+	buf [1]byte
+}
+
+func (b *byteReader) readByte() byte {
+	if b.err != nil {
+		return 0
+	}
+	if _, err := io.ReadFull(b.r, b.buf[:]); err != nil {
+		b.err = err
+		return 0
+	}
+	b.n++
+	return b.buf[0]
+}
+
+// applyDelta rebuilds an object from its base and a git delta.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	pos := 0
+	readSize := func() uint64 {
+		var size uint64
+		for shift := uint(0); pos < len(delta); shift += 7 {
+			c := delta[pos]
+			pos++
+			size |= uint64(c&0x7f) << shift
+			if c&0x80 == 0 {
+				break
+			}
+		}
+		return size
+	}
+
+	if readSize() != uint64(len(base)) {
+		return nil, fmt.Errorf("delta base size mismatch")
+	}
+	want := readSize()
+	out := make([]byte, 0, want)
+	for pos < len(delta) {
+		op := delta[pos]
+		pos++
+		if op&0x80 == 0 {
+			// Insert the next op bytes
+			n := int(op)
+			if n == 0 || pos+n > len(delta) {
+				return nil, fmt.Errorf("malformed delta")
+			}
+			out = append(out, delta[pos:pos+n]...)
+			pos += n
+			continue
+		}
+		// Copy from the base; the low bits select which offset and size bytes follow
+		var off, size uint64
+		for i := uint(0); i < 4; i++ {
+			if op&(1<<i) != 0 {
+				if pos >= len(delta) {
+					return nil, fmt.Errorf("malformed delta")
+				}
+				off |= uint64(delta[pos]) << (8 * i)
+				pos++
+			}
+		}
+		for i := uint(0); i < 3; i++ {
+			if op&(0x10<<i) != 0 {
+				if pos >= len(delta) {
+					return nil, fmt.Errorf("malformed delta")
+				}
+				size |= uint64(delta[pos]) << (8 * i)
+				pos++
+			}
+		}
+		if size == 0 {
+			size = 0x10000
+		}
+		if off+size > uint64(len(base)) {
+			return nil, fmt.Errorf("malformed delta")
+		}
+		out = append(out, base[off:off+size]...)
+	}
+	if uint64(len(out)) != want {
+		return nil, fmt.Errorf("delta result size mismatch")
+	}
+	return out, nil
+}
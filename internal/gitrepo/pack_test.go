@@ -0,0 +1,248 @@
+package gitrepo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyDelta(t *testing.T) {
+	base := []byte("the quick brown fox")
+	long := bytes.Repeat([]byte("x"), 0x10000+10)
+	tests := []struct {
+		name    string
+		base    []byte
+		delta   []byte
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "insert only",
+			base:  base,
+			delta: []byte{19, 3, 3, 'a', 'b', 'c'},
+			want:  "abc",
+		},
+		{
+			name: "copy and insert",
+			base: base,
+			// copy 4 bytes at 0, insert "slow", copy 10 bytes at 9
+			delta: []byte{19, 18, 0x90, 4, 4, 's', 'l', 'o', 'w', 0x91, 9, 10},
+			want:  "the slow brown fox",
+		},
+		{
+			name:  "copy with a two-byte offset",
+			base:  long,
+			delta: []byte{0x8a, 0x80, 0x04, 3, 0x93, 0x00, 0x01, 3},
+			want:  "xxx",
+		},
+		{
+			name:  "copy size 0 means 0x10000",
+			base:  long,
+			delta: []byte{0x8a, 0x80, 0x04, 0x80, 0x80, 0x04, 0x80},
+			want:  string(long[:0x10000]),
+		},
+		{
+			name:    "base size mismatch",
+			base:    base,
+			delta:   []byte{18, 3, 3, 'a', 'b', 'c'},
+			wantErr: "delta base size mismatch",
+		},
+		{
+			name:    "result size mismatch",
+			base:    base,
+			delta:   []byte{19, 4, 3, 'a', 'b', 'c'},
+			wantErr: "delta result size mismatch",
+		},
+		{
+			name:    "zero insert",
+			base:    base,
+			delta:   []byte{19, 0, 0},
+			wantErr: "malformed delta",
+		},
+		{
+			name:    "insert beyond the delta",
+			base:    base,
+			delta:   []byte{19, 3, 3, 'a'},
+			wantErr: "malformed delta",
+		},
+		{
+			name:    "truncated copy",
+			base:    base,
+			delta:   []byte{19, 4, 0x91, 0},
+			wantErr: "malformed delta",
+		},
+		{
+			name:    "copy beyond the base",
+			base:    base,
+			delta:   []byte{19, 4, 0x91, 16, 4},
+			wantErr: "malformed delta",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyDelta(tt.base, tt.delta)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("applyDelta = %q, %v; want error %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyDelta: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("applyDelta = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// writeIndex writes a version 2 pack index of sorted hashes and offsets,
+// storing the offsets of 2 GiB and above in the large offset table.
+func writeIndex(t *testing.T, hashes []Hash, offsets []uint64) string {
+	t.Helper()
+	var b bytes.Buffer
+	b.Write([]byte{0xff, 't', 'O', 'c', 0, 0, 0, 2})
+	var fanout [256]uint32
+	for _, h := range hashes {
+		for i := int(h[0]); i < 256; i++ {
+			fanout[i]++
+		}
+	}
+	binary.Write(&b, binary.BigEndian, fanout)
+	for _, h := range hashes {
+		b.Write(h[:])
+	}
+	b.Write(make([]byte, 4*len(hashes)))
+	var large []uint64
+	for _, off := range offsets {
+		if off < 0x80000000 {
+			binary.Write(&b, binary.BigEndian, uint32(off))
+			continue
+		}
+		binary.Write(&b, binary.BigEndian, uint32(0x80000000|len(large)))
+		large = append(large, off)
+	}
+	binary.Write(&b, binary.BigEndian, large)
+	path := filepath.Join(t.TempDir(), "pack.idx")
+	if err := os.WriteFile(path, b.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadPackIndex(t *testing.T) {
+	h1 := mustHash(t, "0100000000000000000000000000000000000000")
+	h2 := mustHash(t, "01ff000000000000000000000000000000000000")
+	h3 := mustHash(t, "fe00000000000000000000000000000000000000")
+	missing := mustHash(t, "0200000000000000000000000000000000000000")
+
+	t.Run("fixture", func(t *testing.T) {
+		paths, _ := filepath.Glob(filepath.Join("testdata", "repo.git", "objects", "pack", "*.idx"))
+		if len(paths) != 1 {
+			t.Fatalf("want one pack index in the fixture, found %d", len(paths))
+		}
+		idx, err := readPackIndex(paths[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(idx.hashes) != 18 {
+			t.Errorf("got %d objects, want 18", len(idx.hashes))
+		}
+		for _, tt := range []struct {
+			hash   string
+			offset uint64
+		}{
+			{mergeCommit, 12},
+			{renameCommit, 184},
+			{tagV1, 564},
+		} {
+			if off, ok := idx.find(mustHash(t, tt.hash)); !ok || off != tt.offset {
+				t.Errorf("find(%s) = %d, %v; want %d", tt.hash, off, ok, tt.offset)
+			}
+		}
+		if _, ok := idx.find(missing); ok {
+			t.Errorf("find(%s) found an object not in the pack", missing)
+		}
+	})
+
+	t.Run("large offsets", func(t *testing.T) {
+		offsets := []uint64{12, 0x80000000, 0x123456789}
+		idx, err := readPackIndex(writeIndex(t, []Hash{h1, h2, h3}, offsets))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, h := range []Hash{h1, h2, h3} {
+			if off, ok := idx.find(h); !ok || off != offsets[i] {
+				t.Errorf("find(%s) = %#x, %v; want %#x", h, off, ok, offsets[i])
+			}
+		}
+		if _, ok := idx.find(missing); ok {
+			t.Errorf("find(%s) found an object not in the index", missing)
+		}
+	})
+
+	valid, err := os.ReadFile(writeIndex(t, []Hash{h1, h2, h3}, []uint64{12, 0x80000000, 40}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"version 1", []byte(strings.Repeat("\x00", 8+256*4+20)), "unsupported pack index version"},
+		{"version 3", append([]byte{0xff, 't', 'O', 'c', 0, 0, 0, 3}, valid[8:]...), "unsupported pack index version"},
+		{"short header", valid[:100], "unsupported pack index version"},
+		{"truncated hashes", valid[:8+256*4+30], "truncated pack index"},
+		{"missing large offset", valid[:len(valid)-8], "truncated pack index"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "pack.idx")
+			if err := os.WriteFile(path, tt.data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := readPackIndex(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("readPackIndex = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBlob(t *testing.T) {
+	var seq strings.Builder
+	for i := 1; i <= 2000; i++ {
+		fmt.Fprintf(&seq, "%d\n", i)
+	}
+	// The first big.txt is stored in the pack as a delta on the second one
+	first := seq.String()
+	second := strings.Replace(first, "\n1000\n", "\none thousand\n", 1)
+	tests := []struct {
+		hash string
+		want string
+	}{
+		{"ce013625030ba8dba906f756967f9e9ca394464a", "hello\n"},
+		{"7972c09aa90a9b3d8519064681f2cca009f8777c", first},
+		{"dbd76b1c15c7f2996384aad22a122e845315cbd9", second},
+	}
+	for _, fixture := range fixtures {
+		r := openFixture(t, fixture)
+		for _, tt := range tests {
+			t.Run(fixture+"/"+tt.hash[:7], func(t *testing.T) {
+				got, err := r.Blob(mustHash(t, tt.hash))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != tt.want {
+					t.Errorf("Blob(%s) has %d bytes, want %d", tt.hash, len(got), len(tt.want))
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,357 @@
+// Package gitrepo reads git repositories directly from the object database,
+// without requiring the git binary: references, loose and packed objects,
+// commits, trees and tree diffs.
+package gitrepo
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrNotRepository is returned by Open when no repository contains the path.
+var ErrNotRepository = errors.New("not inside a git repository")
+
+// Hash is a SHA-1 object name.
+type Hash [20]byte
+
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// IsZero reports whether h is the zero hash.
+func (h Hash) IsZero() bool {
+	return h == Hash{}
+}
+
+// ParseHash decodes a full 40-character hexadecimal object name.
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+	if len(s) != 40 {
+		return h, fmt.Errorf("invalid object name %q", s)
+	}
+	if _, err := hex.Decode(h[:], []byte(s)); err != nil {
+		return h, fmt.Errorf("invalid object name %q", s)
+	}
+	return h, nil
+}
+
+// BlobHash returns the object name git gives to a file with this content.
+func BlobHash(content []byte) Hash {
+	sum := sha1.New()
+	fmt.Fprintf(sum, "blob %d\x00", len(content))
+	sum.Write(content)
+	var h Hash
+	copy(h[:], sum.Sum(nil))
+	return h
+}
+
+// Repo is an opened repository.
+type Repo struct {
+	// GitDir is the repository's .git directory
+	GitDir string
+	// WorkTree is the root of the working tree
+	WorkTree string
+
+	commonDir string
+	packsOnce sync.Once
+	packs     []*pack
+	packsErr  error
+
+	mu    sync.Mutex
+	cache map[Hash]*object
+
+	shallowOnce sync.Once
+	shallow     map[Hash]bool
+}
+
+// Open finds the repository containing path, looking in path and its parents.
+func Open(path string) (*Repo, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		gitPath := filepath.Join(dir, ".git")
+		if info, err := os.Stat(gitPath); err == nil {
+			gitDir := gitPath
+			if !info.IsDir() {
+				// Worktrees and submodules use a "gitdir: <path>" file
+				if gitDir, err = readGitFile(gitPath); err != nil {
+					return nil, err
+				}
+			}
+			return openGitDir(gitDir, dir)
+		}
+		if dir == filepath.Dir(dir) {
+			return nil, ErrNotRepository
+		}
+	}
+}
+
+func readGitFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, "gitdir:") {
+		return "", fmt.Errorf("%s: unexpected content", path)
+	}
+	dir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(path), dir)
+	}
+	return dir, nil
+}
+
+func openGitDir(gitDir, workTree string) (*Repo, error) {
+	r := &Repo{GitDir: gitDir, WorkTree: workTree, commonDir: gitDir, cache: make(map[Hash]*object)}
+	// Linked worktrees keep their objects and branches in the main repository
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		common := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(gitDir, common)
+		}
+		r.commonDir = filepath.Clean(common)
+	}
+	if data, err := os.ReadFile(filepath.Join(r.commonDir, "config")); err == nil {
+		if strings.Contains(strings.ToLower(string(data)), "objectformat = sha256") {
+			return nil, fmt.Errorf("SHA-256 repositories are not supported")
+		}
+	}
+	return r, nil
+}
+
+// Resolve turns a revision into a commit hash. Supported forms are full and
+// abbreviated object names, HEAD, branch, tag and remote-tracking names, and
+// any of these followed by ~N or ^ (first parent) suffixes. Annotated tags
+// are peeled to their commit.
+func (r *Repo) Resolve(rev string) (Hash, error) {
+	rev = strings.TrimSpace(rev)
+	base, suffix := rev, ""
+	if i := strings.IndexAny(rev, "~^"); i >= 0 {
+		base, suffix = rev[:i], rev[i:]
+	}
+
+	h, err := r.resolveName(base)
+	if err != nil {
+		return Hash{}, err
+	}
+	if h, err = r.peelToCommit(h); err != nil {
+		return Hash{}, err
+	}
+
+	for suffix != "" {
+		op := suffix[0]
+		suffix = suffix[1:]
+		n := 1
+		digits := 0
+		for digits < len(suffix) && suffix[digits] >= '0' && suffix[digits] <= '9' {
+			digits++
+		}
+		if digits > 0 {
+			n, _ = strconv.Atoi(suffix[:digits])
+			suffix = suffix[digits:]
+		}
+		if op == '^' && n != 1 {
+			// ^N selects the Nth parent
+			c, err := r.Commit(h)
+			if err != nil {
+				return Hash{}, err
+			}
+			if n == 0 {
+				continue
+			}
+			if n > len(c.Parents) {
+				return Hash{}, fmt.Errorf("%s: commit has no parent %d", rev, n)
+			}
+			h = c.Parents[n-1]
+			continue
+		}
+		for i := 0; i < n; i++ {
+			c, err := r.Commit(h)
+			if err != nil {
+				return Hash{}, err
+			}
+			if len(c.Parents) == 0 {
+				return Hash{}, fmt.Errorf("%s: reaches beyond the root commit", rev)
+			}
+			h = c.Parents[0]
+		}
+	}
+	return h, nil
+}
+
+func (r *Repo) resolveName(name string) (Hash, error) {
+	if name == "" || name == "@" {
+		name = "HEAD"
+	}
+	candidates := []string{name, "refs/" + name, "refs/tags/" + name, "refs/heads/" + name, "refs/remotes/" + name, "refs/remotes/" + name + "/HEAD"}
+	for _, ref := range candidates {
+		if h, err := r.readRef(ref, 0); err == nil {
+			return h, nil
+		}
+	}
+	if len(name) == 40 {
+		if h, err := ParseHash(name); err == nil {
+			return h, nil
+		}
+	}
+	if len(name) >= 4 && isHex(name) {
+		return r.expandPrefix(strings.ToLower(name))
+	}
+	return Hash{}, fmt.Errorf("unknown revision %q", name)
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// readRef resolves a reference, following symbolic references.
+func (r *Repo) readRef(name string, depth int) (Hash, error) {
+	if depth > 10 {
+		return Hash{}, fmt.Errorf("reference %s: too many levels of symbolic references", name)
+	}
+	dirs := []string{r.GitDir}
+	if r.commonDir != r.GitDir {
+		dirs = append(dirs, r.commonDir)
+	}
+	for _, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			continue
+		}
+		line := strings.TrimSpace(string(data))
+		if strings.HasPrefix(line, "ref:") {
+			return r.readRef(strings.TrimSpace(strings.TrimPrefix(line, "ref:")), depth+1)
+		}
+		return ParseHash(line)
+	}
+	if h, ok := r.packedRef(name); ok {
+		return h, nil
+	}
+	return Hash{}, fmt.Errorf("reference %s not found", name)
+}
+
+func (r *Repo) packedRef(name string) (Hash, bool) {
+	f, err := os.Open(filepath.Join(r.commonDir, "packed-refs"))
+	if err != nil {
+		return Hash{}, false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == name {
+			h, err := ParseHash(fields[0])
+			return h, err == nil
+		}
+	}
+	return Hash{}, false
+}
+
+// expandPrefix finds the unique object whose name starts with prefix.
+func (r *Repo) expandPrefix(prefix string) (Hash, error) {
+	var found []Hash
+	seen := make(map[Hash]bool)
+	add := func(h Hash) {
+		if !seen[h] {
+			seen[h] = true
+			found = append(found, h)
+		}
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(r.commonDir, "objects", prefix[:2]))
+	for _, e := range entries {
+		if name := prefix[:2] + e.Name(); strings.HasPrefix(name, prefix) {
+			if h, err := ParseHash(name); err == nil {
+				add(h)
+			}
+		}
+	}
+	packs, err := r.loadPacks()
+	if err != nil {
+		return Hash{}, err
+	}
+	for _, p := range packs {
+		for _, h := range p.idx.withPrefix(prefix) {
+			add(h)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return Hash{}, fmt.Errorf("unknown revision %q", prefix)
+	case 1:
+		return found[0], nil
+	}
+	return Hash{}, fmt.Errorf("ambiguous revision %q", prefix)
+}
+
+func (r *Repo) peelToCommit(h Hash) (Hash, error) {
+	for i := 0; i < 10; i++ {
+		obj, err := r.object(h)
+		if err != nil {
+			return Hash{}, err
+		}
+		switch obj.kind {
+		case kindCommit:
+			return h, nil
+		case kindTag:
+			target, err := parseTagTarget(obj.data)
+			if err != nil {
+				return Hash{}, err
+			}
+			h = target
+		default:
+			return Hash{}, fmt.Errorf("%s is a %s, not a commit", h, obj.kind)
+		}
+	}
+	return Hash{}, fmt.Errorf("%s: too many levels of tags", h)
+}
+
+func parseTagTarget(data []byte) (Hash, error) {
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "object ") {
+			return ParseHash(strings.TrimPrefix(line, "object "))
+		}
+		if line == "" {
+			break
+		}
+	}
+	return Hash{}, fmt.Errorf("malformed tag object")
+}
+
+// RelPath returns path relative to the working tree, slash-separated, or
+// false when path is outside of it.
+func (r *Repo) RelPath(path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(r.WorkTree, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	if rel == "." {
+		return "", true
+	}
+	return filepath.ToSlash(rel), true
+}
@@ -0,0 +1,98 @@
+package gitrepo
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The fixture history, built by testdata/make-repo.sh, newest first:
+//
+//	merge feature      (main), parents: rename b, edit a; feature
+//	feature            (feature), parent: edit big
+//	rename b, edit a   parent: edit big
+//	edit big           (annotated tag v1), parent: initial
+//	initial
+const (
+	mergeCommit   = "a486b7688e0affe0639640d1d8590a83c9d6bf24"
+	renameCommit  = "e458e1c89151546f6955b04d0884266bbadd0032"
+	featureCommit = "a710c88aa8bfd2da08d2da92e5c57e4a641f800e"
+	editCommit    = "8842074c60d9353e88a0e856b611b434e48e08f1"
+	initialCommit = "aa2d2aa6a1d5c3a45c23c4e9458d898b5a4e02f4"
+	tagV1         = "ba07d89f667ab8d8b4749422f71da0564b3a78f5"
+)
+
+// fixtures are the same repository packed with deltas and as loose objects.
+var fixtures = []string{"repo.git", "loose.git"}
+
+func openFixture(t *testing.T, name string) *Repo {
+	t.Helper()
+	r, err := openGitDir(filepath.Join("testdata", name), "")
+	if err != nil {
+		t.Fatalf("opening %s: %v", name, err)
+	}
+	return r
+}
+
+func mustHash(t *testing.T, s string) Hash {
+	t.Helper()
+	h, err := ParseHash(s)
+	if err != nil {
+		t.Fatalf("ParseHash(%q): %v", s, err)
+	}
+	return h
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		rev     string
+		want    string
+		wantErr string
+	}{
+		{rev: "HEAD", want: mergeCommit},
+		{rev: "@", want: mergeCommit},
+		{rev: "main", want: mergeCommit},
+		{rev: "refs/heads/main", want: mergeCommit},
+		{rev: "feature", want: featureCommit},
+		{rev: mergeCommit, want: mergeCommit},
+		{rev: "a486b76", want: mergeCommit},
+		{rev: "HEAD^", want: renameCommit},
+		{rev: "HEAD^1", want: renameCommit},
+		{rev: "HEAD^2", want: featureCommit},
+		{rev: "HEAD^0", want: mergeCommit},
+		{rev: "HEAD~", want: renameCommit},
+		{rev: "HEAD~1", want: renameCommit},
+		{rev: "HEAD~2", want: editCommit},
+		{rev: "HEAD^^", want: editCommit},
+		{rev: "HEAD~3", want: initialCommit},
+		{rev: "HEAD^2~1", want: editCommit},
+		{rev: "HEAD~1^", want: editCommit},
+		{rev: "v1", want: editCommit},
+		{rev: tagV1, want: editCommit},
+		{rev: "v1~1", want: initialCommit},
+		{rev: "HEAD~4", wantErr: "beyond the root commit"},
+		{rev: "HEAD^3", wantErr: "no parent 3"},
+		{rev: "nope", wantErr: "unknown revision"},
+		{rev: "a48", wantErr: "unknown revision"},
+	}
+	for _, fixture := range fixtures {
+		r := openFixture(t, fixture)
+		for _, tt := range tests {
+			t.Run(fixture+"/"+tt.rev, func(t *testing.T) {
+				got, err := r.Resolve(tt.rev)
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("Resolve(%q) = %s, %v; want error containing %q", tt.rev, got, err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Resolve(%q): %v", tt.rev, err)
+				}
+				if got.String() != tt.want {
+					t.Errorf("Resolve(%q) = %s, want %s", tt.rev, got, tt.want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,66 @@
+#!/bin/sh
+# Rebuilds testdata/repo.git, testdata/loose.git and testdata/shallow.git,
+# the fixtures of the gitrepo tests: the same history, packed with deltas, as
+# loose objects, and as a shallow clone of depth 2. Dates and identities are
+# fixed so that the object names do not change; the expected hashes in the
+# tests must be updated if this script is.
+set -e
+cd "$(dirname "$0")"
+rm -rf repo.git loose.git shallow.git work
+git init -q -b main work
+cd work
+export GIT_AUTHOR_NAME=Alice GIT_AUTHOR_EMAIL=alice@example.com
+export GIT_COMMITTER_NAME=Alice GIT_COMMITTER_EMAIL=alice@example.com
+commit() {
+	GIT_AUTHOR_DATE="$1 +0000" GIT_COMMITTER_DATE="$1 +0000" git commit -q -m "$2"
+}
+
+printf 'hello\n' > a.txt
+mkdir dir
+printf 'bee\n' > dir/b.txt
+seq 1 2000 > big.txt
+git add .
+commit 1700000000 "initial"
+
+seq 1 2000 | sed 's/^1000$/one thousand/' > big.txt
+git add .
+commit 1700000100 "edit big"
+GIT_COMMITTER_DATE="1700000100 +0000" git tag -a v1 -m "v1"
+
+git checkout -q -b feature
+printf 'feature\n' > feature.txt
+git add .
+commit 1700000200 "feature"
+
+git checkout -q main
+mkdir lib
+git mv dir/b.txt lib/b.txt
+printf 'hello world\n' > a.txt
+git add .
+commit 1700000300 "rename b, edit a"
+
+GIT_AUTHOR_DATE="1700000400 +0000" GIT_COMMITTER_DATE="1700000400 +0000" git merge -q --no-ff -m "merge feature" feature
+
+git gc -q --aggressive
+cd ..
+git clone -q --bare work repo.git
+rm -rf work
+cd repo.git
+git remote remove origin
+git gc -q --aggressive
+rm -rf hooks logs info description FETCH_HEAD ORIG_HEAD objects/info objects/pack/*.bitmap
+cd ..
+
+git init -q --bare loose.git
+cp repo.git/HEAD repo.git/packed-refs loose.git/
+for pack in repo.git/objects/pack/*.pack; do
+	git -C loose.git unpack-objects -q < "$pack"
+done
+cd loose.git
+rm -rf hooks logs info description objects/info
+cd ..
+
+git clone -q --bare --depth 2 "file://$PWD/repo.git" shallow.git
+cd shallow.git
+git remote remove origin
+rm -rf branches hooks logs info description FETCH_HEAD objects/info
@@ -0,0 +1,95 @@
+package gitrepo
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DiffWorkTree lists the files that differ between commit from and the
+// working tree, sorted by path. The files considered are those tracked at
+// from or at HEAD, so committed changes and uncommitted edits are reported
+// while untracked files are not. Only paths under prefix (slash-separated,
+// relative to the working tree, "" for all) are compared. The To hash of a
+// change is the blob hash of the file on disk.
+func (r *Repo) DiffWorkTree(from Hash, prefix string) ([]Change, error) {
+	base, err := r.Commit(from)
+	if err != nil {
+		return nil, err
+	}
+	old, err := r.Files(base.Tree)
+	if err != nil {
+		return nil, err
+	}
+	tracked := make(map[string]bool, len(old))
+	for p := range old {
+		tracked[p] = true
+	}
+	if head, err := r.Resolve("HEAD"); err == nil && head != from {
+		c, err := r.Commit(head)
+		if err != nil {
+			return nil, err
+		}
+		current, err := r.Files(c.Tree)
+		if err != nil {
+			return nil, err
+		}
+		for p := range current {
+			tracked[p] = true
+		}
+	}
+
+	prefix = strings.Trim(prefix, "/")
+	var changes []Change
+	for p := range tracked {
+		if prefix != "" && p != prefix && !strings.HasPrefix(p, prefix+"/") {
+			continue
+		}
+		cur, exists, err := r.workTreeBlob(p)
+		if err != nil {
+			return nil, err
+		}
+		was, inOld := old[p]
+		switch {
+		case inOld && !exists:
+			changes = append(changes, Change{Path: p, Action: Deleted, From: was})
+		case !inOld && exists:
+			changes = append(changes, Change{Path: p, Action: Added, To: cur})
+		case inOld && was != cur:
+			changes = append(changes, Change{Path: p, Action: Modified, From: was, To: cur})
+		}
+	}
+	changes = detectRenames(changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// workTreeBlob returns the blob hash of a file in the working tree. Symbolic
+// links hash their target, as git stores them.
+func (r *Repo) workTreeBlob(rel string) (Hash, bool, error) {
+	path := filepath.Join(r.WorkTree, filepath.FromSlash(rel))
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return Hash{}, false, nil
+	}
+	if err != nil {
+		return Hash{}, false, err
+	}
+	if info.IsDir() {
+		// A file replaced by a directory is gone as a file
+		return Hash{}, false, nil
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return Hash{}, false, err
+		}
+		return BlobHash([]byte(target)), true, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Hash{}, false, err
+	}
+	return BlobHash(content), true, nil
+}